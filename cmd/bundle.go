@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"gunp/internal/gunp"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var bundleDest string
+
+func init() {
+	bundleCmd.PersistentFlags().StringVar(&bundleDest, "dest", "", "directory of the bundles and of their manifest")
+	bundleCmd.MarkPersistentFlagRequired("dest")
+	bundleCmd.AddCommand(bundleVerifyCmd)
+	rootCmd.AddCommand(bundleCmd)
+}
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Back up the unpushed branches of every repo as git bundles",
+	Long: `Write a git bundle per repo with the unpushed branches of the repo, down to their merge bases with the upstream,
+and a manifest mapping the bundles to the repos and branches they were made from.
+
+A bundle is restored with git fetch <bundle> or git clone <bundle> (the repo must have the prerequisites, see git bundle verify).
+`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manifest, err := gunp.Bundle(bundleDest)
+		for _, entry := range manifest.Bundles {
+			fmt.Printf("%s: %d branches -> %s\n", entry.Path, len(entry.Branches), filepath.Join(bundleDest, entry.File))
+		}
+		fmt.Printf("%d bundles, manifest: %s\n", len(manifest.Bundles), filepath.Join(bundleDest, gunp.ManifestFile))
+		return err
+	},
+}
+
+var bundleVerifyCmd = &cobra.Command{
+	Use:          "verify",
+	Short:        "Check the bundles of a manifest written by gunp bundle",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		checks, err := gunp.VerifyBundles(bundleDest)
+		if err != nil {
+			return err
+		}
+		failed := 0
+		for _, check := range checks {
+			switch {
+			case check.Err != nil:
+				failed++
+				fmt.Printf("FAIL %s: %v\n", check.Entry.File, check.Err)
+			case check.MissingRepo:
+				fmt.Printf("ok   %s (%s is gone, the prerequisites were not checked)\n", check.Entry.File, check.Entry.Path)
+			default:
+				fmt.Printf("ok   %s\n", check.Entry.File)
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d bundles failed the verification", failed, len(checks))
+		}
+		return nil
+	},
+}
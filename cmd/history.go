@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"gunp/internal/gunp"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// historySparklineScans is the number of scans drawn by the sparkline
+const historySparklineScans = 40
+
+var historyJSON bool
+var historyWeeks int
+
+func init() {
+	historyCmd.Flags().BoolVar(&historyJSON, "json", false, "print the snapshots as JSON")
+	historyCmd.Flags().IntVar(&historyWeeks, "weeks", 12, "number of weeks shown")
+	rootCmd.AddCommand(historyCmd)
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show how the unpushed commits evolved across the scans of the current folder",
+	Long: `Show how the unpushed commits evolved across the scans of the current folder, week over week.
+
+Every scan without --author, --mine, --since, --until or --hide-equivalent is kept as a snapshot in $XDG_STATE_HOME/gunp/history.
+`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootDir, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		history, err := gunp.History(rootDir)
+		if err != nil {
+			return err
+		}
+
+		if historyJSON {
+			if history == nil {
+				history = []gunp.Snapshot{}
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(history)
+		}
+
+		if len(history) == 0 {
+			fmt.Printf("No scan of %s in the history yet, run gunp first\n", rootDir)
+			return nil
+		}
+		first, last := history[0], history[len(history)-1]
+		fmt.Printf("History of %s: %d scans from %s to %s\n", rootDir, len(history), first.Time.Format("2006-01-02"), last.Time.Format("2006-01-02"))
+
+		recent := history[max(0, len(history)-historySparklineScans):]
+		var totals []int
+		for _, snapshot := range recent {
+			totals = append(totals, snapshot.Unpushed)
+		}
+		fmt.Printf("%s (last %d scans)\n\n", gunp.Sparkline(totals), len(totals))
+
+		weekly := gunp.Weekly(history)
+		fmt.Printf("%-8s  %8s  %5s  %6s\n", "Week", "Unpushed", "Repos", "Change")
+		for i, snapshot := range weekly {
+			if i < len(weekly)-historyWeeks {
+				continue
+			}
+			year, week := snapshot.Time.ISOWeek()
+			change := ""
+			if i > 0 {
+				change = fmt.Sprintf("%+d", snapshot.Unpushed-weekly[i-1].Unpushed)
+			}
+			fmt.Printf("%d-W%02d  %8d  %5d  %6s\n", year, week, snapshot.Unpushed, len(snapshot.Repos), change)
+		}
+
+		fmt.Printf("\nLast scan, %s: %d unpushed commits in %d repos\n", last.Time.Format("2006-01-02 15:04"), last.Unpushed, len(last.Repos))
+		for _, repo := range last.Repos {
+			branch := ""
+			if repo.Branch != "" {
+				branch = " (" + repo.Branch + ")"
+			}
+			fmt.Printf("  %4d  %s%s\n", repo.Ahead, repo.Path, branch)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"gunp/internal/gunp"
+
+	"github.com/spf13/cobra"
+)
+
+var patchesDest string
+
+func init() {
+	exportPatchesCmd.Flags().StringVar(&patchesDest, "dest", "", "directory of the patches, one folder per repo and branch")
+	exportPatchesCmd.MarkFlagRequired("dest")
+	rootCmd.AddCommand(exportPatchesCmd)
+}
+
+var exportPatchesCmd = &cobra.Command{
+	Use:   "export-patches",
+	Short: "Export the unpushed commits of every repo as patch series, like git format-patch",
+	Long: `Write the unpushed commits of the branches of every repo as patches in the mbox format of git format-patch,
+one folder per repo and branch, to send them by email or attach them for a review when pushing is not allowed.
+
+A series is applied with git am <dir>/*.patch.
+`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		series, err := gunp.ExportPatches(patchesDest)
+		patches := 0
+		for _, s := range series {
+			patches += s.Patches
+			fmt.Printf("%s (%s): %d patches -> %s\n", s.Path, s.Branch, s.Patches, s.Dir)
+		}
+		fmt.Printf("%d patches in %d series\n", patches, len(series))
+		return err
+	},
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"gunp/internal/gunp"
+
+	"github.com/spf13/cobra"
+)
+
+var restoreForce bool
+
+func init() {
+	restoreCmd.Flags().BoolVar(&restoreForce, "force", false, "overwrite the local branches that diverged from the saved ones")
+	rootCmd.AddCommand(restoreCmd)
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <archive>",
+	Short: "Restore the unpushed branches saved by gunp bundle",
+	Long: `Read the manifest of a backup written by gunp bundle (its folder or the manifest.json) and bring the saved branches back
+into the repos they were made from. A repo that is not at its path anymore is cloned again from its remote.
+
+A missing branch is created and a branch behind the saved one is fast-forwarded. A branch that diverged is left as is, unless --force.
+`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		results, err := gunp.Restore(args[0], restoreForce)
+		if err != nil {
+			return err
+		}
+		failed, diverged := 0, 0
+		path := ""
+		for _, result := range results {
+			if result.Entry.Path != path {
+				path = result.Entry.Path
+				if result.Recreated {
+					fmt.Printf("%s (recreated)\n", path)
+				} else {
+					fmt.Println(path)
+				}
+			}
+			switch result.Status {
+			case gunp.RestoreFailed:
+				failed++
+				fmt.Printf("  %s: %s: %v\n", result.Branch.Name, result.Status, result.Err)
+				continue
+			case gunp.RestoreDiverged:
+				diverged++
+			}
+			fmt.Printf("  %s: %s\n", result.Branch.Name, result.Status)
+		}
+		if failed > 0 || diverged > 0 {
+			return fmt.Errorf("%d branches failed, %d diverged", failed, diverged)
+		}
+		return nil
+	},
+}
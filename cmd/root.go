@@ -1,15 +1,49 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
 	"gunp/internal/app"
+	"gunp/internal/config"
+	"gunp/internal/gunp"
 	logger "gunp/internal/log"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
+var opts gunp.Options
+var jsonOutput bool
+var staleAfter string
+var configPath string
+var cfg config.Config
+var logLevel string
+var author string
+var mine bool
+var since string
+var until string
+var logOpts logger.Options
+
 func init() {
 	// rootCmd.PersistentFlags().StringP("path", "p", "", "use a different directory instead of the cwd")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "silent", "minimum level of the logs: trace, dev, debug, info, warn, error, silent")
+	rootCmd.PersistentFlags().StringVar(&logOpts.File, "log-file", "", "write the logs to a file instead of stdout")
+	rootCmd.PersistentFlags().StringVar(&logOpts.Format, "log-format", "text", "format of the logs: text or json")
+	rootCmd.Flags().BoolVar(&opts.Cherry, "cherry", false, "mark unpushed commits whose changes are already upstream (like git cherry)")
+	rootCmd.Flags().BoolVar(&opts.HideEquivalent, "hide-equivalent", false, "hide unpushed commits whose changes are already upstream (implies --cherry)")
+	rootCmd.Flags().BoolVar(&opts.Tags, "tags", false, "report local tags that are not on the remote")
+	rootCmd.Flags().BoolVar(&opts.RemoteTags, "remote-tags", false, "list the tags of the remotes to refresh the record of the last-known remote tags (implies --tags)")
+	rootCmd.Flags().StringVar(&staleAfter, "stale-after", "", "mark the results of repos whose remotes were not fetched for longer as unreliable (e.g. 36h, 7d, 2w)")
+	rootCmd.Flags().StringVar(&author, "author", "", "count only the unpushed commits whose author matches, a regex on \"Name <email>\" like git log --author")
+	rootCmd.Flags().BoolVar(&mine, "mine", false, "count only your unpushed commits, the author is the user.email of the git config of each repo")
+	rootCmd.Flags().StringVar(&since, "since", "", "count only the unpushed commits committed after a date (e.g. 2024-03-15) or an age (e.g. 2w)")
+	rootCmd.Flags().StringVar(&until, "until", "", "count only the unpushed commits committed before a date (e.g. 2024-03-29) or an age (e.g. 1w)")
+	rootCmd.Flags().BoolVar(&opts.Fetch, "fetch", false, "fetch the remotes of every repo before scanning")
+	rootCmd.Flags().IntVar(&opts.FetchJobs, "fetch-jobs", 4, "number of repos fetched concurrently with --fetch")
+	rootCmd.Flags().DurationVar(&opts.FetchTimeout, "fetch-timeout", time.Minute, "timeout of the fetch of a single repo with --fetch")
+	rootCmd.Flags().StringVar(&configPath, "config", config.DefaultPath(), "config file of the keys bindings and the theme of the Terminal UI")
+	rootCmd.Flags().BoolVar(&jsonOutput, "json", false, "print the results as JSON instead of starting the Terminal UI")
 }
 
 // rootCmd represents the base command when called without any subcommands
@@ -21,9 +55,84 @@ var rootCmd = &cobra.Command{
 Recursively scan git repos for unpushed commits with a nice Terminal UI
 `,
 	// Args: cobra.ExactArgs(1),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		level, err := logger.ParseLevel(logLevel)
+		if err != nil {
+			return fmt.Errorf("--log-level: %w", err)
+		}
+		logOpts.Level = level
+		if err := logger.Configure(logOpts); err != nil {
+			return fmt.Errorf("--log-file/--log-format: %w", err)
+		}
+		return nil
+	},
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if staleAfter != "" {
+			age, err := gunp.ParseAge(staleAfter)
+			if err != nil {
+				return fmt.Errorf("--stale-after: %w", err)
+			}
+			opts.StaleAfter = age
+		}
+		if since != "" {
+			t, err := gunp.ParseDate(since, false)
+			if err != nil {
+				return fmt.Errorf("--since: %w", err)
+			}
+			opts.Since = &t
+		}
+		if until != "" {
+			t, err := gunp.ParseDate(until, true)
+			if err != nil {
+				return fmt.Errorf("--until: %w", err)
+			}
+			opts.Until = &t
+		}
+		if opts.Since != nil && opts.Until != nil && opts.Until.Before(*opts.Since) {
+			return fmt.Errorf("--until %s is before --since %s", until, since)
+		}
+		var err error
+		if opts.Author, err = gunp.AuthorPattern(author); err != nil {
+			return fmt.Errorf("--author: %w", err)
+		}
+		opts.Mine = mine
+		if cfg, err = config.Load(configPath); err != nil {
+			return fmt.Errorf("--config: %w", err)
+		}
+		if err := app.ValidateConfig(cfg); err != nil {
+			return fmt.Errorf("%s: %w", configPath, err)
+		}
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		// path := args[0]
-		app.StartUnpushedApp()
+		if jsonOutput {
+			rootDir, gunpRepos := gunp.Scan(opts)
+			if !opts.Filtered() {
+				if err := gunp.SaveSnapshot(gunp.NewSnapshot(rootDir, gunpRepos)); err != nil {
+					logger.Get().Error("save snapshot", "rootDir", rootDir, "err", err)
+				}
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(gunp.NewReport(rootDir, gunpRepos, opts)); err != nil {
+				logger.Get().Error("json output", "err", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		logger.Get().Print("gunp - Git Unpushed")
+		logger.Get().Print("By running gunp it will recursively explore all folders starting from the current, and count the unpushed commits of your git repositories.")
+
+		// the logs would be written over the Terminal UI
+		logOpts.TUI = true
+		if err := logger.Configure(logOpts); err != nil {
+			logger.Get().Error("configure the logs", "err", err)
+			os.Exit(1)
+		}
+
+		app.StartUnpushedApp(opts, cfg)
 	},
 }
 
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"fmt"
+	"gunp/internal/gunp"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+)
+
+// toastDuration is how long a toast stays in the status line
+const toastDuration = 3 * time.Second
+
+type copiedMsg struct {
+	what string
+	// file is where the text was written when the terminal has no OSC 52 support
+	file string
+	err  error
+}
+
+type toastExpiredMsg struct {
+	id int
+}
+
+// copyCmd copies the text to the clipboard of the terminal with OSC 52, which works over SSH too.
+// Terminals without OSC 52 get the text in a file instead.
+func copyCmd(what string, text string) tea.Cmd {
+	return func() tea.Msg {
+		if osc52Supported() {
+			seq := osc52.New(text)
+			if os.Getenv("TMUX") != "" {
+				seq = seq.Tmux()
+			} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+				seq = seq.Screen()
+			}
+			// stderr is not written by the renderer, the sequence can't end up in the middle of a frame
+			if _, err := seq.WriteTo(os.Stderr); err == nil {
+				return copiedMsg{what: what}
+			}
+		}
+		file, err := writeClipboardFile(text)
+		return copiedMsg{what: what, file: file, err: err}
+	}
+}
+
+func toastExpiredCmd(id int) tea.Cmd {
+	return tea.Tick(toastDuration, func(time.Time) tea.Msg {
+		return toastExpiredMsg{id: id}
+	})
+}
+
+// osc52Supported excludes the terminals known to ignore OSC 52, there is no way to ask the others
+func osc52Supported() bool {
+	if !term.IsTerminal(os.Stderr.Fd()) {
+		return false
+	}
+	switch os.Getenv("TERM") {
+	case "", "dumb", "linux":
+		return false
+	}
+	return true
+}
+
+// writeClipboardFile writes the text to $XDG_CACHE_HOME/gunp/clipboard.txt
+func writeClipboardFile(text string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	file := filepath.Join(dir, "gunp", "clipboard.txt")
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return "", err
+	}
+	return file, os.WriteFile(file, []byte(text), 0o644)
+}
+
+// unpushedSummary lists the unpushed commits and tags of every repo, to paste in a chat or a ticket
+func unpushedSummary(repos []*gunp.GunpRepo) string {
+	repos = append([]*gunp.GunpRepo{}, repos...)
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].Path < repos[j].Path
+	})
+
+	var b strings.Builder
+	commits, withWork := 0, 0
+	for _, repo := range repos {
+		if len(repo.UnpushedCommits) == 0 && len(repo.UnpushedTags) == 0 {
+			continue
+		}
+		commits += len(repo.UnpushedCommits)
+		withWork++
+		fmt.Fprintf(&b, "\n%s (%d unpushed)\n", repo.Path, len(repo.UnpushedCommits))
+		for _, c := range repo.UnpushedCommits {
+			fmt.Fprintf(&b, "  %s %s\n", c.Hash.String()[:7], strings.SplitN(strings.TrimSpace(c.Message), "\n", 2)[0])
+		}
+		if len(repo.UnpushedTags) > 0 {
+			fmt.Fprintf(&b, "  tags: %s\n", strings.Join(repo.UnpushedTags, ", "))
+		}
+	}
+	return fmt.Sprintf("gunp: %d unpushed commits in %d repositories\n", commits, withWork) + b.String()
+}
+
+func uiCopied(msg copiedMsg) string {
+	switch {
+	case msg.err != nil:
+		return fmt.Sprintf("✘ Cannot copy the %s: %v", msg.what, msg.err)
+	case msg.file != "":
+		return fmt.Sprintf("✔ Copied the %s to %s", msg.what, msg.file)
+	}
+	return fmt.Sprintf("✔ Copied the %s to the clipboard", msg.what)
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"gunp/internal/gunp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+// diffView is the scrollable diff of a single commit
+type diffView struct {
+	title    string
+	viewport viewport.Model
+	// files holds the line of every file header, to jump between files
+	files []int
+}
+
+type diffMsg struct {
+	title   string
+	content string
+	files   []int
+	err     error
+}
+
+// diffCmd computes the stats and the colorized unified diff of a commit against its parent
+func diffCmd(c *object.Commit) tea.Cmd {
+	return func() tea.Msg {
+		title := fmt.Sprintf("%s %s", c.Hash.String()[:7], strings.SplitN(strings.TrimSpace(c.Message), "\n", 2)[0])
+
+		patch, err := gunp.CommitPatch(c)
+		if err != nil {
+			return diffMsg{title: title, err: err}
+		}
+		var unified bytes.Buffer
+		if err := patch.Encode(&unified); err != nil {
+			return diffMsg{title: title, err: err}
+		}
+
+		var lines []string
+		var files []int
+		lines = append(lines,
+			fmt.Sprintf("Author: %s", c.Author.String()),
+			fmt.Sprintf("Date:   %s", c.Author.When.Format(time.RFC1123)),
+			"",
+		)
+		for _, line := range strings.Split(strings.TrimRight(c.Message, "\n"), "\n") {
+			lines = append(lines, "    "+line)
+		}
+		lines = append(lines, "")
+		lines = append(lines, strings.Split(strings.TrimRight(patch.Stats().String(), "\n"), "\n")...)
+		lines = append(lines, "")
+
+		for _, line := range strings.Split(strings.TrimRight(unified.String(), "\n"), "\n") {
+			if strings.HasPrefix(line, "diff --git ") {
+				files = append(files, len(lines))
+			}
+			lines = append(lines, colorizeDiffLine(line))
+		}
+
+		return diffMsg{title: title, content: strings.Join(lines, "\n"), files: files}
+	}
+}
+
+func colorizeDiffLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "diff --git "):
+		return DiffFileStyle().Render(line)
+	case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		return DiffFileStyle().Render(line)
+	case strings.HasPrefix(line, "@@"):
+		return DiffHunkStyle().Render(line)
+	case strings.HasPrefix(line, "+"):
+		return DiffAddStyle().Render(line)
+	case strings.HasPrefix(line, "-"):
+		return DiffDeleteStyle().Render(line)
+	}
+	return line
+}
+
+func newDiffView(msg diffMsg, width int, height int, keys keyMap) *diffView {
+	vp := viewport.New(width, height)
+	vp.KeyMap = keys.viewportKeyMap()
+	if msg.err != nil {
+		vp.SetContent(StaleStyle().Render("Cannot compute the diff: " + msg.err.Error()))
+	} else {
+		vp.SetContent(msg.content)
+	}
+	return &diffView{
+		title:    msg.title,
+		viewport: vp,
+		files:    msg.files,
+	}
+}
+
+func (d *diffView) setSize(width int, height int) {
+	d.viewport.Width = width
+	d.viewport.Height = height
+}
+
+// nextFile scrolls to the next file header after the top of the viewport
+func (d *diffView) nextFile() {
+	for _, line := range d.files {
+		if line > d.viewport.YOffset {
+			d.viewport.SetYOffset(line)
+			return
+		}
+	}
+}
+
+// prevFile scrolls to the previous file header before the top of the viewport
+func (d *diffView) prevFile() {
+	for i := len(d.files) - 1; i >= 0; i-- {
+		if d.files[i] < d.viewport.YOffset {
+			d.viewport.SetYOffset(d.files[i])
+			return
+		}
+	}
+	d.viewport.GotoTop()
+}
+
+func (d *diffView) View(helpText string) string {
+	footer := fmt.Sprintf("%3.f%% · %s", d.viewport.ScrollPercent()*100, helpText)
+	return TableWrapperStyle().
+		Padding(0, 1).
+		Render(lipgloss.JoinVertical(lipgloss.Left, d.title, "", d.viewport.View(), "", footer))
+}
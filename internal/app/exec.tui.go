@@ -0,0 +1,105 @@
+package app
+
+import (
+	"fmt"
+	"gunp/internal/gunp"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type execDoneMsg struct {
+	path   string
+	action string
+	err    error
+}
+
+type rescanMsg struct {
+	gunpRepo *gunp.GunpRepo
+}
+
+// execCmd suspends the TUI to run the command in the repo, the repo is re-scanned on return
+func execCmd(action string, command []string, path string) tea.Cmd {
+	c := exec.Command(command[0], command[1:]...)
+	c.Dir = path
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		return execDoneMsg{path: path, action: action, err: err}
+	})
+}
+
+// rescanRepoCmd re-scans a single repo, e.g. after a commit from the editor or the shell
+func rescanRepoCmd(path string, opts gunp.Options) tea.Cmd {
+	return func() tea.Msg {
+		return rescanMsg{gunpRepo: gunp.GitStats(path, opts)}
+	}
+}
+
+// editorCommand is $VISUAL, or $EDITOR, or vi, opening the repo directory
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.Fields(os.Getenv(env)); len(editor) > 0 {
+			return append(editor, ".")
+		}
+	}
+	return []string{"vi", "."}
+}
+
+// shellCommand is $SHELL, or the default shell of the OS
+func shellCommand() []string {
+	if shell := strings.Fields(os.Getenv("SHELL")); len(shell) > 0 {
+		return shell
+	}
+	if runtime.GOOS == "windows" {
+		if comspec := strings.TrimSpace(os.Getenv("COMSPEC")); comspec != "" {
+			return []string{comspec}
+		}
+		return []string{"cmd"}
+	}
+	return []string{"sh"}
+}
+
+// openerCommand is the opener of the config, or the file manager of the OS, opening the repo directory
+func openerCommand(opener string) []string {
+	if command := strings.Fields(opener); len(command) > 0 {
+		return append(command, ".")
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"open", "."}
+	case "windows":
+		return []string{"explorer", "."}
+	}
+	return []string{"xdg-open", "."}
+}
+
+func uiExecResult(msg execDoneMsg) string {
+	return fmt.Sprintf("✘ %s failed", msg.action)
+}
+
+// cursorRepoPath is the path of the repo under the cursor, of the table or of the tree view
+func (m unpushedAppModel) cursorRepoPath() (string, bool) {
+	i, ok := m.cursorRepoIdx()
+	if !ok {
+		return "", false
+	}
+	return m.gunpRepos[i].Path, true
+}
+
+// cursorRepoIdx is the index in gunpRepos of the repo under the cursor,
+// none on the directories of the tree view
+func (m unpushedAppModel) cursorRepoIdx() (int, bool) {
+	if m.treeView {
+		node := m.treeCursor()
+		if node == nil || node.repoIdx < 0 {
+			return 0, false
+		}
+		return node.repoIdx, true
+	}
+	if len(m.table.Rows()) == 0 {
+		return 0, false
+	}
+	return m.cursorRepo, true
+}
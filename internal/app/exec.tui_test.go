@@ -0,0 +1,27 @@
+package app
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCommandsFallBackOnBlankValues(t *testing.T) {
+	t.Setenv("VISUAL", "  ")
+	t.Setenv("EDITOR", "\t")
+	t.Setenv("SHELL", " ")
+
+	if got := editorCommand(); !slices.Equal(got, []string{"vi", "."}) {
+		t.Errorf("got the editor %q, want vi", got)
+	}
+	if got := shellCommand(); len(got) == 0 || got[0] == "" {
+		t.Errorf("got the shell %q, want the default one", got)
+	}
+	if got := openerCommand("   "); len(got) != 2 || got[0] == "." {
+		t.Errorf("got the opener %q, want the file manager of the OS", got)
+	}
+
+	t.Setenv("EDITOR", "code --wait")
+	if got := editorCommand(); !slices.Equal(got, []string{"code", "--wait", "."}) {
+		t.Errorf("got the editor %q, want $EDITOR", got)
+	}
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"gunp/internal/gunp"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type fetchResultMsg struct {
+	result   gunp.FetchResult
+	gunpRepo *gunp.GunpRepo
+}
+
+// fetchRepoCmd fetches a single repo and re-scans it afterwards
+func fetchRepoCmd(path string, opts gunp.Options) tea.Cmd {
+	return func() tea.Msg {
+		err := gunp.FetchRepo(path, opts.FetchTimeout)
+		gunpRepo := gunp.GitStats(path, opts)
+		gunpRepo.FetchErr = err
+		return fetchResultMsg{result: gunp.FetchResult{Path: path, Err: err}, gunpRepo: gunpRepo}
+	}
+}
+
+func uiFetchResult(result gunp.FetchResult) string {
+	if result.Err != nil {
+		return "✘ fetch failed"
+	}
+	return "✔ fetched"
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"fmt"
+	"gunp/internal/gunp"
+	logger "gunp/internal/log"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sparklineScans is the number of scans drawn by the sparkline of the title
+const sparklineScans = 20
+
+type historyMsg struct {
+	history []gunp.Snapshot
+}
+
+// loadHistoryCmd reads the history of the scans of rootDir
+func loadHistoryCmd(rootDir string) tea.Cmd {
+	return func() tea.Msg {
+		history, err := gunp.History(rootDir)
+		if err != nil {
+			logger.Get().Error("load history", "rootDir", rootDir, "err", err)
+		}
+		return historyMsg{history: history}
+	}
+}
+
+// saveSnapshotCmd adds the scan to the history, then reads it again
+func saveSnapshotCmd(rootDir string, gunpRepos []*gunp.GunpRepo) tea.Cmd {
+	snapshot := gunp.NewSnapshot(rootDir, gunpRepos)
+	return func() tea.Msg {
+		if err := gunp.SaveSnapshot(snapshot); err != nil {
+			logger.Get().Error("save snapshot", "rootDir", rootDir, "err", err)
+		}
+		return loadHistoryCmd(rootDir)()
+	}
+}
+
+// uiSparkline draws the unpushed commits of the last scans, colored by their trend
+func (m unpushedAppModel) uiSparkline() string {
+	history := m.history
+	if len(history) > sparklineScans {
+		history = history[len(history)-sparklineScans:]
+	}
+	if len(history) < 2 {
+		return ""
+	}
+	var totals []int
+	for _, snapshot := range history {
+		totals = append(totals, snapshot.Unpushed)
+	}
+	change := totals[len(totals)-1] - totals[0]
+	return TrendStyle(change).Render(fmt.Sprintf("%s %+d over %d scans", gunp.Sparkline(totals), change, len(totals)))
+}
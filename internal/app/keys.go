@@ -0,0 +1,396 @@
+package app
+
+import (
+	"fmt"
+	"gunp/internal/config"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/viewport"
+)
+
+// keyMap holds every key binding of the TUI, the help and the footer are built from it
+type keyMap struct {
+	// global
+	Quit      key.Binding
+	ForceQuit key.Binding
+	Help      key.Binding
+	CloseHelp key.Binding
+	// repository table
+	Up           key.Binding
+	Down         key.Binding
+	Detail       key.Binding
+	Refresh      key.Binding
+	Select       key.Binding
+	SelectAll    key.Binding
+	SelectNone   key.Binding
+	Push         key.Binding
+	PushSelected key.Binding
+	Fetch        key.Binding
+	Sort         key.Binding
+	SortReverse  key.Binding
+	Filter       key.Binding
+	Tree         key.Binding
+	Editor       key.Binding
+	Shell        key.Binding
+	Open         key.Binding
+	CopyPath     key.Binding
+	CopySummary  key.Binding
+	Logs         key.Binding
+	// tree view
+	Expand   key.Binding
+	Collapse key.Binding
+	Toggle   key.Binding
+	// detail view
+	Diff        key.Binding
+	CloseDetail key.Binding
+	CopyHash    key.Binding
+	// diff view
+	NextFile key.Binding
+	PrevFile key.Binding
+	Back     key.Binding
+	// log panel
+	CloseLogs key.Binding
+	LogLevel  key.Binding
+	// dialogs and prompts
+	Confirm     key.Binding
+	Cancel      key.Binding
+	ApplyFilter key.Binding
+	ClearFilter key.Binding
+}
+
+func defaultKeyMap() keyMap {
+	return keyMap{
+		Quit:         key.NewBinding(key.WithKeys("q", "esc"), key.WithHelp("q", "quit")),
+		ForceQuit:    key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", "quit")),
+		Help:         key.NewBinding(key.WithKeys("h", "?"), key.WithHelp("h", "help")),
+		CloseHelp:    key.NewBinding(key.WithKeys("h", "?", "esc"), key.WithHelp("h/esc", "close")),
+		Up:           key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+		Down:         key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+		Detail:       key.NewBinding(key.WithKeys("enter", "v"), key.WithHelp("enter/v", "detail")),
+		Refresh:      key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "refresh")),
+		Select:       key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "select")),
+		SelectAll:    key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "select all")),
+		SelectNone:   key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "select none")),
+		Push:         key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "push")),
+		PushSelected: key.NewBinding(key.WithKeys("P"), key.WithHelp("P", "push selected")),
+		Fetch:        key.NewBinding(key.WithKeys("f"), key.WithHelp("f", "fetch selected")),
+		Sort:         key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "sort")),
+		SortReverse:  key.NewBinding(key.WithKeys("shift+tab"), key.WithHelp("shift+tab", "reverse sort")),
+		Filter:       key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "filter")),
+		Tree:         key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "tree view")),
+		Editor:       key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "editor")),
+		Shell:        key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "shell")),
+		Open:         key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "open")),
+		CopyPath:     key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "copy path")),
+		CopySummary:  key.NewBinding(key.WithKeys("C"), key.WithHelp("C", "copy summary")),
+		Logs:         key.NewBinding(key.WithKeys("L"), key.WithHelp("L", "logs")),
+		CloseLogs:    key.NewBinding(key.WithKeys("L", "esc"), key.WithHelp("L/esc", "close")),
+		LogLevel:     key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "level")),
+		Expand:       key.NewBinding(key.WithKeys("right"), key.WithHelp("→", "expand")),
+		Collapse:     key.NewBinding(key.WithKeys("left"), key.WithHelp("←", "collapse")),
+		Toggle:       key.NewBinding(key.WithKeys("z"), key.WithHelp("z", "expand/collapse")),
+		Diff:         key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "diff")),
+		CloseDetail:  key.NewBinding(key.WithKeys("v", "esc"), key.WithHelp("v/esc", "close")),
+		CopyHash:     key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "copy hash")),
+		NextFile:     key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "next file")),
+		PrevFile:     key.NewBinding(key.WithKeys("N"), key.WithHelp("N", "previous file")),
+		Back:         key.NewBinding(key.WithKeys("esc", "backspace"), key.WithHelp("esc", "back")),
+		Confirm:      key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "confirm")),
+		Cancel:       key.NewBinding(key.WithKeys("n", "esc"), key.WithHelp("n/esc", "cancel")),
+		ApplyFilter:  key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "apply")),
+		ClearFilter:  key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "clear")),
+	}
+}
+
+// actions names the bindings of the keyMap, as used in the config file
+func (k *keyMap) actions() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"quit":          &k.Quit,
+		"force_quit":    &k.ForceQuit,
+		"help":          &k.Help,
+		"close_help":    &k.CloseHelp,
+		"up":            &k.Up,
+		"down":          &k.Down,
+		"detail":        &k.Detail,
+		"refresh":       &k.Refresh,
+		"select":        &k.Select,
+		"select_all":    &k.SelectAll,
+		"select_none":   &k.SelectNone,
+		"push":          &k.Push,
+		"push_selected": &k.PushSelected,
+		"fetch":         &k.Fetch,
+		"sort":          &k.Sort,
+		"sort_reverse":  &k.SortReverse,
+		"filter":        &k.Filter,
+		"tree":          &k.Tree,
+		"editor":        &k.Editor,
+		"shell":         &k.Shell,
+		"open":          &k.Open,
+		"copy_path":     &k.CopyPath,
+		"copy_summary":  &k.CopySummary,
+		"copy_hash":     &k.CopyHash,
+		"logs":          &k.Logs,
+		"close_logs":    &k.CloseLogs,
+		"log_level":     &k.LogLevel,
+		"expand":        &k.Expand,
+		"collapse":      &k.Collapse,
+		"toggle":        &k.Toggle,
+		"diff":          &k.Diff,
+		"close_detail":  &k.CloseDetail,
+		"next_file":     &k.NextFile,
+		"prev_file":     &k.PrevFile,
+		"back":          &k.Back,
+		"confirm":       &k.Confirm,
+		"cancel":        &k.Cancel,
+		"apply_filter":  &k.ApplyFilter,
+		"clear_filter":  &k.ClearFilter,
+	}
+}
+
+// keyPresets are applied on top of the default bindings
+var keyPresets = map[string]map[string][]string{
+	"default": {},
+	"vim": {
+		"help":       {"?"},
+		"close_help": {"?", "esc"},
+		"expand":     {"l", "right"},
+		"collapse":   {"h", "left"},
+	},
+	"emacs": {
+		"up":           {"ctrl+p", "up"},
+		"down":         {"ctrl+n", "down"},
+		"expand":       {"ctrl+f", "right"},
+		"collapse":     {"ctrl+b", "left"},
+		"filter":       {"ctrl+s", "/"},
+		"close_detail": {"ctrl+g", "v", "esc"},
+		"back":         {"ctrl+g", "esc", "backspace"},
+		"cancel":       {"ctrl+g", "n", "esc"},
+		"clear_filter": {"ctrl+g", "esc"},
+		"close_help":   {"ctrl+g", "h", "esc"},
+	},
+}
+
+// keyContexts lists the actions handled together by a view of the TUI, the same key can't be bound twice in a view.
+// The keys of the table movements are included, as the table handles them along the actions.
+var keyContexts = map[string][]string{
+	"table":   {"force_quit", "quit", "help", "up", "down", "detail", "refresh", "select", "select_all", "select_none", "push", "push_selected", "fetch", "sort", "sort_reverse", "filter", "tree", "editor", "shell", "open", "copy_path", "copy_summary", "logs"},
+	"tree":    {"force_quit", "quit", "help", "up", "down", "detail", "refresh", "select", "select_all", "select_none", "push", "push_selected", "fetch", "sort", "sort_reverse", "filter", "tree", "editor", "shell", "open", "copy_path", "copy_summary", "logs", "expand", "collapse", "toggle"},
+	"detail":  {"force_quit", "help", "up", "down", "diff", "close_detail", "copy_hash", "copy_summary"},
+	"diff":    {"force_quit", "help", "up", "down", "next_file", "prev_file", "back"},
+	"help":    {"force_quit", "close_help"},
+	"logs":    {"force_quit", "help", "up", "down", "close_logs", "log_level"},
+	"confirm": {"force_quit", "confirm", "cancel"},
+	"filter":  {"force_quit", "apply_filter", "clear_filter"},
+}
+
+// tableMovementKeys are the paging keys of the tables, see tableKeyMap
+var tableMovementKeys = []string{"pgup", "pgdown", "ctrl+u", "ctrl+d", "home", "g", "end", "G"}
+
+// newKeyMap applies the preset and the bindings of the config to the default bindings,
+// and checks that no key is bound to two actions of the same view
+func newKeyMap(cfg config.Keys) (keyMap, error) {
+	k := defaultKeyMap()
+	actions := k.actions()
+
+	preset := cfg.Preset
+	if preset == "" {
+		preset = "default"
+	}
+	presetBindings, ok := keyPresets[preset]
+	if !ok {
+		return k, fmt.Errorf("unknown keys preset %q (available: default, vim, emacs)", preset)
+	}
+	for action, keys := range presetBindings {
+		rebind(actions[action], keys)
+	}
+	for action, keys := range cfg.Bindings {
+		binding, ok := actions[action]
+		if !ok {
+			return k, fmt.Errorf("unknown action %q in the keys bindings", action)
+		}
+		if len(keys) == 0 {
+			return k, fmt.Errorf("no keys bound to the action %q", action)
+		}
+		rebind(binding, keys)
+	}
+
+	contexts := make([]string, 0, len(keyContexts))
+	for context := range keyContexts {
+		contexts = append(contexts, context)
+	}
+	sort.Strings(contexts)
+	var conflicts []string
+	for _, context := range contexts {
+		boundTo := map[string]string{}
+		if context == "table" || context == "tree" {
+			for _, movement := range tableMovementKeys {
+				boundTo[movement] = "table movement"
+			}
+		}
+		for _, action := range keyContexts[context] {
+			for _, keyName := range actions[action].Keys() {
+				if other, ok := boundTo[keyName]; ok && other != action {
+					conflicts = append(conflicts, fmt.Sprintf("%q is bound to both %s and %s in the %s view", keyName, other, action, context))
+				}
+				boundTo[keyName] = action
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return k, fmt.Errorf("conflicting keys bindings:\n  %s", strings.Join(conflicts, "\n  "))
+	}
+	return k, nil
+}
+
+// rebind replaces the keys of the binding, the help shows the new keys
+func rebind(binding *key.Binding, keys []string) {
+	names := make([]string, len(keys))
+	for i, keyName := range keys {
+		names[i] = keyHelpName(keyName)
+	}
+	binding.SetKeys(keys...)
+	binding.SetHelp(strings.Join(names, "/"), binding.Help().Desc)
+}
+
+func keyHelpName(keyName string) string {
+	switch keyName {
+	case " ":
+		return "space"
+	case "up":
+		return "↑"
+	case "down":
+		return "↓"
+	case "left":
+		return "←"
+	case "right":
+		return "→"
+	}
+	return keyName
+}
+
+// tableKeyMap moves the cursor of the tables with the keys of the keyMap,
+// the paging keys of the tables are limited to the ones not bound to an action
+func (k keyMap) tableKeyMap() table.KeyMap {
+	km := table.DefaultKeyMap()
+	km.LineUp = k.Up
+	km.LineDown = k.Down
+	km.PageUp = key.NewBinding(key.WithKeys("pgup"), key.WithHelp("pgup", "page up"))
+	km.PageDown = key.NewBinding(key.WithKeys("pgdown"), key.WithHelp("pgdn", "page down"))
+	km.HalfPageUp = key.NewBinding(key.WithKeys("ctrl+u"), key.WithHelp("ctrl+u", "½ page up"))
+	km.HalfPageDown = key.NewBinding(key.WithKeys("ctrl+d"), key.WithHelp("ctrl+d", "½ page down"))
+	km.GotoTop = key.NewBinding(key.WithKeys("home", "g"), key.WithHelp("g/home", "go to start"))
+	km.GotoBottom = key.NewBinding(key.WithKeys("end", "G"), key.WithHelp("G/end", "go to end"))
+	return km
+}
+
+// viewportKeyMap scrolls the diff with the keys of the keyMap, the other keys of the viewport are limited
+// to the ones not bound to an action
+func (k keyMap) viewportKeyMap() viewport.KeyMap {
+	km := viewport.DefaultKeyMap()
+	km.Up = k.Up
+	km.Down = k.Down
+	km.PageUp = key.NewBinding(key.WithKeys("pgup"), key.WithHelp("pgup", "page up"))
+	km.PageDown = key.NewBinding(key.WithKeys("pgdown"), key.WithHelp("pgdn", "page down"))
+	km.HalfPageUp = key.NewBinding(key.WithKeys("ctrl+u"), key.WithHelp("ctrl+u", "½ page up"))
+	km.HalfPageDown = key.NewBinding(key.WithKeys("ctrl+d"), key.WithHelp("ctrl+d", "½ page down"))
+	km.Left = key.NewBinding(key.WithKeys("shift+left"), key.WithHelp("shift+←", "scroll left"))
+	km.Right = key.NewBinding(key.WithKeys("shift+right"), key.WithHelp("shift+→", "scroll right"))
+	return km
+}
+
+// stateKeys implements help.KeyMap with the bindings available in the current state of the TUI
+type stateKeys struct {
+	short []key.Binding
+	full  [][]key.Binding
+}
+
+func (k stateKeys) ShortHelp() []key.Binding {
+	return k.short
+}
+
+func (k stateKeys) FullHelp() [][]key.Binding {
+	return k.full
+}
+
+// helpKeys returns the bindings available right now, for the footer and the help overlay
+func (m unpushedAppModel) helpKeys() stateKeys {
+	k := m.keys
+	switch {
+	case m.logs != nil:
+		return stateKeys{
+			short: []key.Binding{k.Up, k.Down, k.LogLevel, k.CloseLogs},
+			full:  [][]key.Binding{{k.Up, k.Down}, {k.LogLevel, k.CloseLogs}, {k.Help, k.Quit}},
+		}
+	case m.diff != nil:
+		return stateKeys{
+			short: []key.Binding{k.NextFile, k.PrevFile, k.Back, k.Quit},
+			full:  [][]key.Binding{{k.Up, k.Down}, {k.NextFile, k.PrevFile}, {k.Back, k.Help, k.Quit}},
+		}
+	case m.confirmPush != nil:
+		return stateKeys{
+			short: []key.Binding{k.Confirm, k.Cancel},
+			full:  [][]key.Binding{{k.Confirm, k.Cancel}},
+		}
+	case m.filtering:
+		return stateKeys{
+			short: []key.Binding{k.ApplyFilter, k.ClearFilter},
+			full:  [][]key.Binding{{k.ApplyFilter, k.ClearFilter}},
+		}
+	case m.showDetail:
+		return stateKeys{
+			short: []key.Binding{k.Up, k.Down, k.Diff, k.CloseDetail, k.Help},
+			full:  [][]key.Binding{{k.Up, k.Down}, {k.Diff, k.CloseDetail}, {k.CopyHash, k.CopySummary}, {k.Help, k.Quit}},
+		}
+	case m.treeView:
+		return stateKeys{
+			short: []key.Binding{k.Up, k.Down, k.Expand, k.Collapse, k.Detail, k.Tree, k.Help, k.Quit},
+			full: [][]key.Binding{
+				{k.Up, k.Down, k.Expand, k.Collapse, k.Toggle},
+				{k.Detail, k.Tree, k.Filter},
+				{k.Select, k.SelectAll, k.SelectNone},
+				{k.Push, k.PushSelected, k.Fetch, k.Refresh},
+				{k.Editor, k.Shell, k.Open},
+				{k.CopyPath, k.CopySummary, k.Logs},
+				{k.Help, k.Quit},
+			},
+		}
+	}
+
+	switch m.state {
+	case loading, fetching, scanning:
+		if len(m.table.Rows()) == 0 {
+			return stateKeys{
+				short: []key.Binding{k.Help, k.Quit},
+				full:  [][]key.Binding{{k.Help, k.Quit}},
+			}
+		}
+		return stateKeys{
+			short: []key.Binding{k.Up, k.Down, k.Detail, k.Help, k.Quit},
+			full: [][]key.Binding{
+				{k.Up, k.Down, k.Detail},
+				{k.Select, k.SelectAll, k.SelectNone},
+				{k.Sort, k.SortReverse, k.Filter, k.Tree},
+				{k.Editor, k.Shell, k.Open},
+				{k.CopyPath, k.CopySummary, k.Logs},
+				{k.Help, k.Quit},
+			},
+		}
+	case finished:
+		return stateKeys{
+			short: []key.Binding{k.Up, k.Down, k.Detail, k.Select, k.Push, k.Refresh, k.Help, k.Quit},
+			full: [][]key.Binding{
+				{k.Up, k.Down, k.Detail},
+				{k.Select, k.SelectAll, k.SelectNone},
+				{k.Push, k.PushSelected, k.Fetch, k.Refresh},
+				{k.Sort, k.SortReverse, k.Filter, k.Tree},
+				{k.Editor, k.Shell, k.Open},
+				{k.CopyPath, k.CopySummary, k.Logs},
+				{k.Help, k.Quit},
+			},
+		}
+	}
+	return stateKeys{short: []key.Binding{k.Quit}, full: [][]key.Binding{{k.Quit}}}
+}
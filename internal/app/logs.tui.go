@@ -0,0 +1,85 @@
+package app
+
+import (
+	"fmt"
+	logger "gunp/internal/log"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// logRefreshInterval is how often the open log panel shows the new records
+const logRefreshInterval = time.Second
+
+// logLevels are the minimum levels of the log panel filter, in the order they are cycled
+var logLevels = []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError}
+
+// logPanel shows the log records kept in memory, at or above the minimum level
+type logPanel struct {
+	viewport viewport.Model
+	level    int
+}
+
+type logTickMsg struct{}
+
+func logTickCmd() tea.Cmd {
+	return tea.Tick(logRefreshInterval, func(time.Time) tea.Msg {
+		return logTickMsg{}
+	})
+}
+
+func newLogPanel(width int, height int, keys keyMap) *logPanel {
+	vp := viewport.New(width, height)
+	vp.KeyMap = keys.viewportKeyMap()
+	// the errors are what users look for first
+	p := &logPanel{viewport: vp, level: 1}
+	p.refresh()
+	p.viewport.GotoBottom()
+	return p
+}
+
+func (p *logPanel) setSize(width int, height int) {
+	p.viewport.Width = width
+	p.viewport.Height = height
+}
+
+// nextLevel cycles the minimum level shown
+func (p *logPanel) nextLevel() {
+	p.level = (p.level + 1) % len(logLevels)
+	p.refresh()
+	p.viewport.GotoBottom()
+}
+
+// refresh loads the records, following the new ones when scrolled to the bottom
+func (p *logPanel) refresh() {
+	atBottom := p.viewport.AtBottom()
+	var lines []string
+	for _, r := range logger.Records() {
+		if r.Level < logLevels[p.level] {
+			continue
+		}
+		line := r.String()
+		if r.Level >= slog.LevelWarn {
+			line = StaleStyle().Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		lines = append(lines, fmt.Sprintf("No logs at level %s or above", logger.LevelName(logLevels[p.level])))
+	}
+	p.viewport.SetContent(strings.Join(lines, "\n"))
+	if atBottom {
+		p.viewport.GotoBottom()
+	}
+}
+
+func (p *logPanel) View(helpText string) string {
+	title := fmt.Sprintf("Logs · level %s and above", logger.LevelName(logLevels[p.level]))
+	return TableWrapperStyle().
+		Padding(0, 1).
+		Render(lipgloss.JoinVertical(lipgloss.Left, title, "", p.viewport.View(), "", helpText))
+}
@@ -0,0 +1,215 @@
+package app
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// The mouse events are hit-tested against the screen itself: View is rendered again with the cursor row
+// of the table in use, or the overlay, replaced by hitMarker, and the marked rectangle is searched on screen.
+// This way the layout placed by lipgloss and the overlays never needs to be computed twice.
+const hitMarker = "¤"
+
+const doubleClickInterval = 400 * time.Millisecond
+
+// wheelRows is the number of rows scrolled by a step of the mouse wheel
+const wheelRows = 3
+
+type hitTestMode int
+
+const (
+	hitNone hitTestMode = iota
+	hitCursor
+	hitOverlay
+)
+
+// hitRect is a rectangle of the screen, x1 and y1 excluded
+type hitRect struct {
+	x0, y0, x1, y1 int
+}
+
+func (r hitRect) contains(x int, y int) bool {
+	return x >= r.x0 && x < r.x1 && y >= r.y0 && y < r.y1
+}
+
+// tableStyles are the styles of the tables, in the hit-test rendering the cursor row of the active table is marked
+func (m unpushedAppModel) tableStyles(active bool) table.Styles {
+	s := TableStyle()
+	if m.hitTest == hitCursor && active {
+		s.Selected = lipgloss.NewStyle().Transform(markRow)
+	}
+	return s
+}
+
+func markRow(row string) string {
+	return strings.Repeat(hitMarker, ansi.StringWidth(row))
+}
+
+// hitBlock hides the overlay behind a block of hitMarker of the same size in the hit-test rendering
+func (m unpushedAppModel) hitBlock(fg string) string {
+	if m.hitTest != hitOverlay {
+		return fg
+	}
+	width, height := lipgloss.Size(fg)
+	rows := make([]string, height)
+	for i := range rows {
+		rows[i] = strings.Repeat(hitMarker, width)
+	}
+	return strings.Join(rows, "\n")
+}
+
+// hitRectOf renders the screen in the hit-test mode and returns the rectangle covered by hitMarker
+func (m unpushedAppModel) hitRectOf(mode hitTestMode) (hitRect, bool) {
+	m.hitTest = mode
+	r := hitRect{y0: -1}
+	for y, line := range strings.Split(m.View(), "\n") {
+		line = ansi.Strip(line)
+		idx := strings.Index(line, hitMarker)
+		if idx < 0 {
+			continue
+		}
+		if r.y0 < 0 {
+			r.y0 = y
+			r.x0 = ansi.StringWidth(line[:idx])
+			r.x1 = r.x0 + strings.Count(line[idx:], hitMarker)
+		}
+		r.y1 = y + 1
+	}
+	return r, r.y0 >= 0
+}
+
+// activeTable is the table under the mouse: the commits of the detail, the tree, or the repos
+func (m *unpushedAppModel) activeTable() *table.Model {
+	switch {
+	case m.showDetail:
+		return &m.tableCommits
+	case m.treeView:
+		return &m.tableTree
+	}
+	return &m.table
+}
+
+// tableRowAt returns the row of the active table at the screen coordinates, if any
+func (m unpushedAppModel) tableRowAt(x int, y int) (int, bool) {
+	cursorRect, ok := m.hitRectOf(hitCursor)
+	if !ok || x < cursorRect.x0 || x >= cursorRect.x1 {
+		return 0, false
+	}
+	t := *m.activeTable()
+	row := t.Cursor() + y - cursorRect.y0
+
+	// the rows before the first visible one are not on screen, the header is there
+	m.hitTest = hitCursor
+	t.SetStyles(m.tableStyles(true))
+	lines := strings.Split(t.View(), "\n")
+	firstRow := -1
+	for i, line := range lines {
+		if strings.Contains(line, hitMarker) {
+			// the header takes the first 2 lines
+			firstRow = t.Cursor() - (i - 2)
+			break
+		}
+	}
+	if firstRow < 0 || row < firstRow || row >= len(t.Rows()) {
+		return 0, false
+	}
+	return row, true
+}
+
+// updateMouse handles the clicks and the wheel: a click moves the cursor, a double click opens the row
+// like the detail key, a click outside an overlay closes it
+func (m *unpushedAppModel) updateMouse(msg tea.MouseMsg) tea.Cmd {
+	if m.filtering || m.state == errorStatus {
+		return nil
+	}
+
+	if m.showHelp || m.logs != nil || m.diff != nil || m.confirmPush != nil || m.showDetail {
+		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			if overlayRect, ok := m.hitRectOf(hitOverlay); ok && !overlayRect.contains(msg.X, msg.Y) {
+				m.closeOverlay()
+				return nil
+			}
+		}
+		if m.showHelp || m.logs != nil || m.diff != nil || m.confirmPush != nil {
+			// the viewports of the diff and the logs scroll with the wheel by themselves
+			return nil
+		}
+	}
+
+	t := m.activeTable()
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp:
+		t.MoveUp(wheelRows)
+	case msg.Button == tea.MouseButtonWheelDown:
+		t.MoveDown(wheelRows)
+	case msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress:
+		row, ok := m.tableRowAt(msg.X, msg.Y)
+		if !ok {
+			return nil
+		}
+		t.SetCursor(row)
+		doubleClick := row == m.lastClickRow && time.Since(m.lastClick) < doubleClickInterval
+		m.lastClick, m.lastClickRow = time.Now(), row
+		if doubleClick {
+			m.lastClick = time.Time{}
+			m.syncCursor()
+			return m.openRow()
+		}
+	default:
+		return nil
+	}
+	m.syncCursor()
+	return nil
+}
+
+// syncCursor points the selected repo to the row under the cursor, after the cursor moved
+func (m *unpushedAppModel) syncCursor() {
+	switch {
+	case m.showDetail:
+		m.cursorCommit = m.tableCommits.Cursor()
+	case m.treeView:
+		m.syncTreeCursor()
+	default:
+		if selectedRowIdx, err := getSelectedRow(m.table); err == nil {
+			m.cursorRepo = selectedRowIdx
+		}
+	}
+}
+
+// openRow opens the row under the cursor: the diff of a commit, the detail of a repo, or a tree directory
+func (m *unpushedAppModel) openRow() tea.Cmd {
+	switch {
+	case m.showDetail:
+		commits := m.gunpRepos[m.cursorRepo].UnpushedCommits
+		if m.cursorCommit >= 0 && m.cursorCommit < len(commits) {
+			return diffCmd(commits[m.cursorCommit])
+		}
+	case m.treeView:
+		m.openTreeNode()
+	case len(m.table.Rows()) > 0:
+		m.openDetail()
+		return uiUpdateCmd()
+	}
+	return nil
+}
+
+// closeOverlay closes the overlay on top, like its back or cancel key
+func (m *unpushedAppModel) closeOverlay() {
+	switch {
+	case m.showHelp:
+		m.showHelp = false
+	case m.logs != nil:
+		m.logs = nil
+	case m.diff != nil:
+		m.diff = nil
+	case m.confirmPush != nil:
+		m.confirmPush = nil
+	case m.showDetail:
+		m.showDetail = false
+	}
+}
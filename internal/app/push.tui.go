@@ -0,0 +1,70 @@
+package app
+
+import (
+	"fmt"
+	"gunp/internal/gunp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pushConfirm holds the pushes waiting for the user confirmation
+type pushConfirm struct {
+	plans   []*gunp.PushPlan
+	skipped []string
+}
+
+type pushResultMsg struct {
+	result   gunp.PushResult
+	gunpRepo *gunp.GunpRepo
+}
+
+// newPushConfirm plans the push of the current branch of every repo
+func newPushConfirm(gunpRepos []*gunp.GunpRepo) *pushConfirm {
+	confirm := &pushConfirm{}
+	for _, repo := range gunpRepos {
+		plan, err := gunp.PlanPush(repo.Path)
+		if err != nil {
+			confirm.skipped = append(confirm.skipped, fmt.Sprintf("%s: %s", repo.Path, err))
+			continue
+		}
+		confirm.plans = append(confirm.plans, plan)
+	}
+	return confirm
+}
+
+// pushCmd runs the push and re-scans the repo afterwards
+func pushCmd(plan *gunp.PushPlan, opts gunp.Options) tea.Cmd {
+	return func() tea.Msg {
+		result := gunp.Push(plan)
+		return pushResultMsg{result: result, gunpRepo: gunp.GitStats(plan.Path, opts)}
+	}
+}
+
+func uiPushResult(result gunp.PushResult) string {
+	switch result.Status {
+	case gunp.PushOK, gunp.PushUpToDate:
+		return "✔ " + result.Status.String()
+	}
+	return "✘ " + result.Status.String()
+}
+
+func (m unpushedAppModel) uiPushConfirm() string {
+	var b strings.Builder
+	if len(m.confirmPush.plans) > 0 {
+		b.WriteString("Push the following refspecs?\n\n")
+		for _, plan := range m.confirmPush.plans {
+			fmt.Fprintf(&b, "%s\n  %s\n", plan.Path, plan)
+		}
+	} else {
+		b.WriteString("Nothing to push\n")
+	}
+	if len(m.confirmPush.skipped) > 0 {
+		b.WriteString("\nSkipped:\n")
+		for _, skipped := range m.confirmPush.skipped {
+			fmt.Fprintf(&b, "  %s\n", StaleStyle().Render(skipped))
+		}
+	}
+	b.WriteString("\n" + m.uiHelpText())
+	return TableWrapperStyle().Render(b.String())
+}
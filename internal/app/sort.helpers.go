@@ -0,0 +1,91 @@
+package app
+
+import (
+	"gunp/internal/gunp"
+	"sort"
+	"strings"
+)
+
+type sortMode int
+
+const (
+	sortByPath sortMode = iota
+	sortByUnpushed
+	sortByOldest
+	sortByLastFetch
+)
+
+func (s sortMode) String() string {
+	switch s {
+	case sortByUnpushed:
+		return "unpushed"
+	case sortByOldest:
+		return "oldest unpushed"
+	case sortByLastFetch:
+		return "last fetch"
+	}
+	return "path"
+}
+
+// next cycles through the sort modes
+func (s sortMode) next() sortMode {
+	return (s + 1) % (sortByLastFetch + 1)
+}
+
+// sortRepoIndexes sorts the indexes of the repos by the given mode.
+// The natural order is: path A-Z, most unpushed first, oldest unpushed first, least recently fetched first.
+func sortRepoIndexes(indexes []int, repos []*gunp.GunpRepo, mode sortMode, reverse bool) {
+	less := func(a, b *gunp.GunpRepo) bool {
+		switch mode {
+		case sortByUnpushed:
+			if len(a.UnpushedCommits) != len(b.UnpushedCommits) {
+				return len(a.UnpushedCommits) > len(b.UnpushedCommits)
+			}
+		case sortByOldest:
+			oldestA, oldestB := a.OldestUnpushed, b.OldestUnpushed
+			if !oldestA.Equal(oldestB) {
+				// repos without unpushed commits go last
+				if oldestA.IsZero() || oldestB.IsZero() {
+					return oldestB.IsZero()
+				}
+				return oldestA.Before(oldestB)
+			}
+		case sortByLastFetch:
+			if !a.LastFetch.Equal(b.LastFetch) {
+				return a.LastFetch.Before(b.LastFetch)
+			}
+		}
+		return a.Path < b.Path
+	}
+
+	sort.SliceStable(indexes, func(i, j int) bool {
+		a, b := repos[indexes[i]], repos[indexes[j]]
+		if reverse {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+}
+
+// matchFilter reports whether the path contains the filter, or matches it fuzzily:
+// all the characters of the filter appear in the path in the same order.
+func matchFilter(path string, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	path, filter = strings.ToLower(path), strings.ToLower(filter)
+	if strings.Contains(path, filter) {
+		return true
+	}
+
+	remaining := []rune(filter)
+	for _, r := range path {
+		if r == remaining[0] {
+			remaining = remaining[1:]
+			if len(remaining) == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -1,6 +1,10 @@
 package app
 
 import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -9,13 +13,16 @@ func TableStyle() table.Styles {
 	s := table.DefaultStyles()
 	s.Header = s.Header.
 		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("240")).
+		BorderForeground(themeColor(theme.Border)).
 		BorderBottom(true).
 		Bold(true)
 	s.Selected = s.Selected.
-		Foreground(lipgloss.Color("229")).
-		Background(lipgloss.Color("57")).
+		Foreground(themeColor(theme.SelectedForeground)).
+		Background(themeColor(theme.SelectedBackground)).
 		Bold(true)
+	if theme.SelectedBackground == "" {
+		s.Selected = s.Selected.Reverse(true)
+	}
 	return s
 }
 
@@ -23,5 +30,87 @@ func TableWrapperStyle() lipgloss.Style {
 	return lipgloss.NewStyle().
 		Padding(2).
 		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("240"))
+		BorderForeground(themeColor(theme.Border))
+}
+
+func SpinnerStyle() lipgloss.Style {
+	return lipgloss.NewStyle().
+		Foreground(themeColor(theme.Accent))
+}
+
+func ProgressGradient() progress.Option {
+	if theme.ProgressFrom == "" || theme.ProgressTo == "" {
+		// the progress bar doesn't use the renderer of lipgloss, the colors are removed here
+		return func(m *progress.Model) {
+			progress.WithSolidFill(theme.ProgressFrom)(m)
+			m.EmptyColor = theme.Border
+		}
+	}
+	return progress.WithGradient(theme.ProgressFrom, theme.ProgressTo)
+}
+
+func HelpStyles() help.Styles {
+	s := help.New().Styles
+	if theme.Accent == "" {
+		s.ShortKey = lipgloss.NewStyle().Bold(true)
+		s.ShortDesc = lipgloss.NewStyle()
+		s.ShortSeparator = lipgloss.NewStyle().Faint(true)
+		s.Ellipsis = s.ShortSeparator
+		s.FullKey = s.ShortKey
+		s.FullDesc = s.ShortDesc
+		s.FullSeparator = s.ShortSeparator
+	}
+	return s
+}
+
+func StaleStyle() lipgloss.Style {
+	return lipgloss.NewStyle().
+		Foreground(themeColor(theme.Warning)).
+		Bold(true)
+}
+
+// AgeStyle colors the age of the oldest unpushed commit: the older, the more urgent to push
+func AgeStyle(age time.Duration) lipgloss.Style {
+	color := theme.AgeFresh
+	switch {
+	case age >= ageOld:
+		color = theme.AgeOld
+	case age >= ageAging:
+		color = theme.AgeAging
+	}
+	return lipgloss.NewStyle().
+		Foreground(themeColor(color))
+}
+
+// TrendStyle colors the history of the unpushed commits: green when shrinking, red when growing
+func TrendStyle(change int) lipgloss.Style {
+	color := theme.Accent
+	switch {
+	case change < 0:
+		color = theme.AgeFresh
+	case change > 0:
+		color = theme.AgeOld
+	}
+	return lipgloss.NewStyle().
+		Foreground(themeColor(color))
+}
+
+func DiffFileStyle() lipgloss.Style {
+	return lipgloss.NewStyle().
+		Bold(true)
+}
+
+func DiffHunkStyle() lipgloss.Style {
+	return lipgloss.NewStyle().
+		Foreground(themeColor(theme.DiffHunk))
+}
+
+func DiffAddStyle() lipgloss.Style {
+	return lipgloss.NewStyle().
+		Foreground(themeColor(theme.DiffAdd))
+}
+
+func DiffDeleteStyle() lipgloss.Style {
+	return lipgloss.NewStyle().
+		Foreground(themeColor(theme.DiffDelete))
 }
@@ -2,9 +2,14 @@ package app
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 func updateWidthColumns(t table.Model, w int) []table.Column {
@@ -19,13 +24,13 @@ func updateWidthColumns(t table.Model, w int) []table.Column {
 		// default maxWidth to m.width / number of columns
 		maxWidth := (w - takenWidth) / len(columns)
 		for _, r := range rows {
-			minWidthRow := len(r[i])
+			minWidthRow := ansi.StringWidth(r[i])
 			if minWidthRow > maxWidth {
 				maxWidth = minWidthRow
 			}
 
-			if len(columns[i].Title) > maxWidth {
-				maxWidth = len(columns[i].Title)
+			if ansi.StringWidth(columns[i].Title) > maxWidth {
+				maxWidth = ansi.StringWidth(columns[i].Title)
 			}
 
 			if maxWidth > (w / len(columns)) {
@@ -38,6 +43,60 @@ func updateWidthColumns(t table.Model, w int) []table.Column {
 	return columns
 }
 
+// the columns of the repository table that are styled, see repoCellStyle
+const (
+	repoColumnOldest    = 3
+	repoColumnLastFetch = 4
+)
+
+// cellStyler gives the style of a cell of a row, false to leave it as is
+type cellStyler func(row table.Row, col int) (lipgloss.Style, bool)
+
+// styleCells colors the cells of a rendered table.
+// The rows of the bubbles table hold plain text: it truncates the cells counting the escape codes as width,
+// so the cells are styled here, once truncated, finding the rows of the view by their ID in the first column.
+// The selected row is left to the Selected style.
+func styleCells(t table.Model, styles table.Styles, view string, styler cellStyler) string {
+	rows := map[string]int{}
+	for i, row := range t.Rows() {
+		rows[row[0]] = i
+	}
+	columns := t.Columns()
+	frame := styles.Cell.GetHorizontalFrameSize()
+
+	lines := strings.Split(view, "\n")
+	for l, line := range lines {
+		if len(columns) == 0 || columns[0].Width <= 0 {
+			break
+		}
+		id := strings.TrimSpace(ansi.Strip(ansi.Cut(line, 0, columns[0].Width+frame)))
+		r, ok := rows[id]
+		if !ok || r == t.Cursor() {
+			continue
+		}
+		var styled strings.Builder
+		start, prev := 0, 0
+		for c, column := range columns {
+			if column.Width <= 0 {
+				continue
+			}
+			cellStart := start + styles.Cell.GetPaddingLeft()
+			start += column.Width + frame
+			style, ok := styler(t.Rows()[r], c)
+			if !ok {
+				continue
+			}
+			styled.WriteString(ansi.Cut(line, prev, cellStart))
+			styled.WriteString(style.Render(ansi.Strip(ansi.Cut(line, cellStart, cellStart+column.Width))))
+			prev = cellStart + column.Width
+		}
+		styled.WriteString(ansi.TruncateLeft(line, prev, ""))
+		line = styled.String()
+		lines[l] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
 func getSelectedRow(t table.Model) (int, error) {
 	selectedStrIndex := t.SelectedRow()
 	if selectedStrIndex != nil {
@@ -47,3 +106,34 @@ func getSelectedRow(t table.Model) (int, error) {
 	}
 	return -1, errors.New("row not found")
 }
+
+// the age thresholds of the oldest unpushed commit, see AgeStyle
+const (
+	ageAging = 24 * time.Hour
+	ageOld   = 7 * 24 * time.Hour
+)
+
+// humanizeSince formats the time elapsed since t, like "3 days ago".
+func humanizeSince(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d.Minutes()), "minute") + " ago"
+	case d < 24*time.Hour:
+		return plural(int(d.Hours()), "hour") + " ago"
+	default:
+		return plural(int(d.Hours()/24), "day") + " ago"
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"gunp/internal/config"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// builtinThemes are the themes available without config, "no-color" is forced by NO_COLOR
+var builtinThemes = map[string]config.Theme{
+	"dark": {
+		Border:             "240",
+		SelectedForeground: "229",
+		SelectedBackground: "57",
+		Accent:             "69",
+		Warning:            "208",
+		DiffHunk:           "37",
+		DiffAdd:            "34",
+		DiffDelete:         "160",
+		AgeFresh:           "34",
+		AgeAging:           "220",
+		AgeOld:             "160",
+		ProgressFrom:       "#5A56E0",
+		ProgressTo:         "#EE6FF8",
+	},
+	"light": {
+		Border:             "244",
+		SelectedForeground: "231",
+		SelectedBackground: "25",
+		Accent:             "27",
+		Warning:            "166",
+		DiffHunk:           "30",
+		DiffAdd:            "28",
+		DiffDelete:         "124",
+		AgeFresh:           "28",
+		AgeAging:           "136",
+		AgeOld:             "124",
+		ProgressFrom:       "#3C38C0",
+		ProgressTo:         "#B0308C",
+	},
+	"high-contrast": {
+		Border:             "15",
+		SelectedForeground: "0",
+		SelectedBackground: "11",
+		Accent:             "14",
+		Warning:            "9",
+		DiffHunk:           "14",
+		DiffAdd:            "10",
+		DiffDelete:         "9",
+		AgeFresh:           "10",
+		AgeAging:           "11",
+		AgeOld:             "9",
+		ProgressFrom:       "#FFFF00",
+		ProgressTo:         "#FFFF00",
+	},
+	// without colors the selected row is reversed
+	"no-color": {},
+}
+
+// theme is the theme used by the styles, see SetTheme
+var theme = builtinThemes["dark"]
+
+// SetTheme picks the theme of the config, NO_COLOR disables the colors whatever the config
+func SetTheme(cfg config.Config) error {
+	t, err := newTheme(cfg, os.Getenv("NO_COLOR") != "")
+	if err != nil {
+		return err
+	}
+	theme = t
+	if theme == builtinThemes["no-color"] {
+		// NO_COLOR allows the text attributes, the selected row is shown reversed
+		lipgloss.SetColorProfile(termenv.ANSI)
+	}
+	return nil
+}
+
+// ValidateConfig checks the keys bindings and the theme of the config, to fail at startup instead of inside the TUI
+func ValidateConfig(cfg config.Config) error {
+	if _, err := newKeyMap(cfg.Keys); err != nil {
+		return err
+	}
+	if _, err := newTheme(cfg, true); err != nil {
+		return err
+	}
+	return nil
+}
+
+// newTheme resolves the theme of the config. The detection of the background of "auto" is skipped with noColor,
+// as it queries the terminal.
+func newTheme(cfg config.Config, noColor bool) (config.Theme, error) {
+	name := cfg.Theme
+	if name == "" {
+		name = "auto"
+	}
+
+	var t config.Theme
+	if user, ok := cfg.Themes[name]; ok {
+		base := user.Base
+		if base == "" {
+			base = "dark"
+		}
+		baseTheme, ok := builtinThemes[base]
+		if !ok {
+			return t, fmt.Errorf("theme %q: unknown base theme %q (available: dark, light, high-contrast)", name, base)
+		}
+		// the colors set by the user theme replace the ones of the base
+		t = baseTheme
+		data, err := json.Marshal(user)
+		if err != nil {
+			return t, err
+		}
+		if err := json.Unmarshal(data, &t); err != nil {
+			return t, err
+		}
+	} else if builtin, ok := builtinThemes[name]; ok {
+		t = builtin
+	} else if name != "auto" {
+		return t, fmt.Errorf("unknown theme %q (available: auto, dark, light, high-contrast, or a theme of the config)", name)
+	}
+
+	switch {
+	case noColor:
+		return builtinThemes["no-color"], nil
+	case name == "auto" && lipgloss.HasDarkBackground():
+		return builtinThemes["dark"], nil
+	case name == "auto":
+		return builtinThemes["light"], nil
+	}
+	return t, nil
+}
+
+// themeColor is the color of the theme, no color if not set
+func themeColor(c string) lipgloss.TerminalColor {
+	if c == "" {
+		return lipgloss.NoColor{}
+	}
+	return lipgloss.Color(c)
+}
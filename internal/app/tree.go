@@ -0,0 +1,196 @@
+package app
+
+import (
+	"gunp/internal/gunp"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// treeNode is a directory of the tree view, or a repository (which can contain other repositories)
+type treeNode struct {
+	name     string
+	path     string
+	children []*treeNode
+	// repoIdx is the index of the repo in gunpRepos, -1 if not a repo or not scanned yet
+	repoIdx  int
+	isRepo   bool
+	unpushed int
+	repos    int
+}
+
+// buildTree groups the discovered repos by directory, starting from rootDir.
+// The unpushed commits and the repos are aggregated per folder.
+func buildTree(rootDir string, gitPaths []string, gunpRepos []*gunp.GunpRepo, filter string) *treeNode {
+	root := &treeNode{name: filepath.Base(rootDir), path: rootDir, repoIdx: -1}
+
+	scanned := map[string]int{}
+	for i, repo := range gunpRepos {
+		scanned[repo.Path] = i
+	}
+
+	for _, gitPath := range gitPaths {
+		if !matchFilter(gitPath, filter) {
+			continue
+		}
+		rel, err := filepath.Rel(rootDir, gitPath)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+
+		node := root
+		if rel != "." {
+			for _, part := range strings.Split(rel, string(filepath.Separator)) {
+				node = node.child(part)
+			}
+		}
+		node.isRepo = true
+		if i, ok := scanned[gitPath]; ok {
+			node.repoIdx = i
+		}
+	}
+
+	root.aggregate(gunpRepos)
+	root.compress()
+	return root
+}
+
+func (n *treeNode) child(name string) *treeNode {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+	c := &treeNode{name: name, path: filepath.Join(n.path, name), repoIdx: -1}
+	n.children = append(n.children, c)
+	return c
+}
+
+func (n *treeNode) aggregate(gunpRepos []*gunp.GunpRepo) {
+	if n.isRepo {
+		n.repos = 1
+		if n.repoIdx >= 0 {
+			n.unpushed = len(gunpRepos[n.repoIdx].UnpushedCommits)
+		}
+	}
+	sort.Slice(n.children, func(i, j int) bool {
+		return n.children[i].name < n.children[j].name
+	})
+	for _, c := range n.children {
+		c.aggregate(gunpRepos)
+		n.unpushed += c.unpushed
+		n.repos += c.repos
+	}
+}
+
+// compress merges the directories with a single child directory, e.g. "work/clientA"
+func (n *treeNode) compress() {
+	for _, c := range n.children {
+		for !c.isRepo && len(c.children) == 1 {
+			only := c.children[0]
+			only.name = filepath.Join(c.name, only.name)
+			*c = *only
+		}
+		c.compress()
+	}
+}
+
+// flatten returns the visible nodes with their depth, skipping the children of the collapsed nodes
+func (n *treeNode) flatten(collapsed map[string]bool) ([]*treeNode, []int) {
+	var nodes []*treeNode
+	var depths []int
+	var walk func(node *treeNode, depth int)
+	walk = func(node *treeNode, depth int) {
+		nodes = append(nodes, node)
+		depths = append(depths, depth)
+		if collapsed[node.path] {
+			return
+		}
+		for _, c := range node.children {
+			walk(c, depth+1)
+		}
+	}
+	walk(n, 0)
+	return nodes, depths
+}
+
+// updateTreeKey handles the keys of the tree view, returning false for the keys it doesn't handle
+func (m *unpushedAppModel) updateTreeKey(msg tea.KeyMsg) bool {
+	node := m.treeCursor()
+	switch {
+	case key.Matches(msg, m.keys.Tree):
+		m.treeView = false
+		return true
+	case key.Matches(msg, m.keys.Expand):
+		if node != nil {
+			delete(m.collapsed, node.path)
+		}
+	case key.Matches(msg, m.keys.Collapse):
+		if node != nil && len(node.children) > 0 {
+			m.collapsed[node.path] = true
+		}
+	case key.Matches(msg, m.keys.Detail):
+		m.openTreeNode()
+		return true
+	case key.Matches(msg, m.keys.Toggle):
+		if node != nil && len(node.children) > 0 {
+			m.collapsed[node.path] = !m.collapsed[node.path]
+		}
+	default:
+		return false
+	}
+	m.updateTree()
+	return true
+}
+
+// openTreeNode opens the detail of the repo under the cursor, or expands/collapses the directory
+func (m *unpushedAppModel) openTreeNode() {
+	node := m.treeCursor()
+	if node == nil {
+		return
+	}
+	if node.repoIdx >= 0 {
+		m.cursorRepo = node.repoIdx
+		m.openDetail()
+		return
+	}
+	if len(node.children) > 0 {
+		m.collapsed[node.path] = !m.collapsed[node.path]
+		m.updateTree()
+	}
+}
+
+// treeRows renders the visible nodes as the rows of the tree table
+func treeRows(nodes []*treeNode, depths []int, collapsed map[string]bool, selected map[string]bool) []table.Row {
+	rows := make([]table.Row, 0, len(nodes))
+	for i, node := range nodes {
+		marker := ""
+		if len(node.children) > 0 {
+			marker = "▾"
+			if collapsed[node.path] {
+				marker = "▸"
+			}
+		}
+		name := node.name
+		if selected[node.path] {
+			name = "✔ " + name
+		}
+		if node.isRepo {
+			name += " ⎇"
+		} else {
+			name += "/"
+		}
+		rows = append(rows, table.Row{
+			marker,
+			strings.Repeat("  ", depths[i]) + name,
+			strconv.Itoa(node.unpushed),
+			strconv.Itoa(node.repos),
+		})
+	}
+	return rows
+}
@@ -2,24 +2,32 @@ package app
 
 import (
 	"fmt"
+	"gunp/internal/config"
 	"gunp/internal/gunp"
 	logger "gunp/internal/log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/stopwatch"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/rmhubbert/bubbletea-overlay"
 )
 
-func StartUnpushedApp() {
-	m := NewUnpushedModel()
-	p := tea.NewProgram(m, tea.WithAltScreen())
+func StartUnpushedApp(opts gunp.Options, cfg config.Config) {
+	if err := SetTheme(cfg); err != nil {
+		logger.Get().Error("SetTheme", "err", err)
+	}
+	m := NewUnpushedModel(opts, cfg)
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
 	if _, err := p.Run(); err != nil {
 		logger.Get().Error("StartUnpushedApp", "err", err)
 		os.Exit(1)
@@ -31,6 +39,7 @@ type status int
 const (
 	loading status = iota
 	errorStatus
+	fetching
 	scanning
 	finished
 )
@@ -41,30 +50,58 @@ type unpushedAppModel struct {
 	height       int
 	errorMessage string
 	showDetail   bool
+	showHelp     bool
+	hitTest      hitTestMode
+	confirmPush  *pushConfirm
+	diff         *diffView
+	logs         *logPanel
 	cursorRepo   int
 	cursorCommit int
+	lastClick    time.Time
+	lastClickRow int
+	sortBy       sortMode
+	sortReverse  bool
+	filtering    bool
+	treeView     bool
 	// ui elements
 	stopwatch    stopwatch.Model
 	spinner      spinner.Model
 	progress     progress.Model
 	table        table.Model
 	tableCommits table.Model
+	tableTree    table.Model
+	filter       textinput.Model
+	help         help.Model
+	keys         keyMap
 
 	// data
+	opts          gunp.Options
+	opener        string
+	toast         string
+	toastID       int
+	rootDir       string
 	walkedCounter *gunp.Counter
 	unpushedCount int
 	gitPaths      []string
+	fetched       []gunp.FetchResult
+	fetchDone     bool
 	gunpRepos     []*gunp.GunpRepo
+	history       []gunp.Snapshot
+	actionStatus  map[string]string
+	selected      map[string]bool
+	treeNodes     []*treeNode
+	collapsed     map[string]bool
 
 	// channels
 	discoveryDoneCh <-chan bool
 	scanningDoneCh  <-chan bool
 	gitPathsCh      <-chan string
+	fetchedCh       <-chan gunp.FetchResult
 	gunpReposCh     <-chan *gunp.GunpRepo
 }
 
-func NewUnpushedModel() unpushedAppModel {
-	rootDir, discoveryDoneCh, scanningDoneCh, walkedCounter, gitPathsCh, gunpReposCh, err := gunp.GunpTUI()
+func NewUnpushedModel(opts gunp.Options, cfg config.Config) unpushedAppModel {
+	rootDir, discoveryDoneCh, scanningDoneCh, walkedCounter, gitPathsCh, fetchedCh, gunpReposCh, err := gunp.GunpTUI(opts)
 	if err != nil {
 		logger.Get().Error("GunpTUI", "rootDir", rootDir, "err", err)
 		return unpushedAppModel{
@@ -73,14 +110,24 @@ func NewUnpushedModel() unpushedAppModel {
 		}
 	}
 
+	keys, err := newKeyMap(cfg.Keys)
+	if err != nil {
+		logger.Get().Error("newKeyMap", "err", err)
+		keys = defaultKeyMap()
+	}
+
 	uiTable := table.New(
 		table.WithColumns([]table.Column{
 			{Title: "ID"},
 			{Title: "Repository"},
 			{Title: "Unpushed Commits"},
+			{Title: "Oldest"},
+			{Title: "Last Fetch"},
+			{Title: "Status"},
 		}),
 		table.WithFocused(true),
 		table.WithStyles(TableStyle()),
+		table.WithKeyMap(keys.tableKeyMap()),
 	)
 	uiTableCommits := table.New(
 		table.WithColumns([]table.Column{
@@ -88,11 +135,32 @@ func NewUnpushedModel() unpushedAppModel {
 			{Title: "Date"},
 			{Title: "Author"},
 			{Title: "Message"},
+			{Title: "Upstream"},
+		}),
+		table.WithFocused(true),
+		table.WithStyles(TableStyle()),
+		table.WithKeyMap(keys.tableKeyMap()),
+	)
+
+	uiTableTree := table.New(
+		table.WithColumns([]table.Column{
+			{Title: ""},
+			{Title: "Directory"},
+			{Title: "Unpushed Commits"},
+			{Title: "Repositories"},
 		}),
 		table.WithFocused(true),
 		table.WithStyles(TableStyle()),
+		table.WithKeyMap(keys.tableKeyMap()),
 	)
 
+	uiHelp := help.New()
+	uiHelp.Styles = HelpStyles()
+
+	filter := textinput.New()
+	filter.Prompt = "/"
+	filter.Placeholder = "filter by path"
+
 	return unpushedAppModel{
 		state:        loading,
 		width:        0,
@@ -102,18 +170,30 @@ func NewUnpushedModel() unpushedAppModel {
 		cursorCommit: 0,
 		// ui elements
 		stopwatch:    stopwatch.NewWithInterval(time.Millisecond),
-		progress:     progress.New(progress.WithDefaultGradient()),
-		spinner:      spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("69")))),
+		progress:     progress.New(ProgressGradient()),
+		spinner:      spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(SpinnerStyle())),
 		table:        uiTable,
 		tableCommits: uiTableCommits,
+		tableTree:    uiTableTree,
+		filter:       filter,
+		help:         uiHelp,
+		keys:         keys,
 		// data
+		opts:          opts,
+		opener:        cfg.Opener,
+		rootDir:       rootDir,
 		walkedCounter: walkedCounter,
 		gitPaths:      []string{},
+		fetched:       []gunp.FetchResult{},
 		gunpRepos:     []*gunp.GunpRepo{},
+		actionStatus:  map[string]string{},
+		selected:      map[string]bool{},
+		collapsed:     map[string]bool{},
 		// channels
 		discoveryDoneCh: discoveryDoneCh,
 		scanningDoneCh:  scanningDoneCh,
 		gitPathsCh:      gitPathsCh,
+		fetchedCh:       fetchedCh,
 		gunpReposCh:     gunpReposCh,
 	}
 }
@@ -144,6 +224,21 @@ func discoveryCmd(discoveryDoneCh <-chan bool, gitPathsCh <-chan string) tea.Cmd
 	}
 }
 
+type fetchingProgressMsg struct {
+	result gunp.FetchResult
+}
+type fetchingDoneMsg struct{}
+
+func fetchingCmd(fetchedCh <-chan gunp.FetchResult) tea.Cmd {
+	return func() tea.Msg {
+		result, ok := <-fetchedCh
+		if !ok {
+			return fetchingDoneMsg{}
+		}
+		return fetchingProgressMsg{result: result}
+	}
+}
+
 func counterCmd(walkedCounter *gunp.Counter) tea.Cmd {
 	return func() tea.Msg {
 		select {
@@ -169,18 +264,14 @@ func refreshScanningCmd() tea.Cmd {
 
 func scanningCmd(scanningDoneCh <-chan bool, gunpReposCh <-chan *gunp.GunpRepo) tea.Cmd {
 	return func() tea.Msg {
-		select {
-		case _, ok := <-scanningDoneCh:
-			if !ok {
-				return scanningDoneMsg{}
-			}
+		// drain the repos before reporting the end of the scan,
+		// every scanned repo is a row of the table
+		gunpRepo, ok := <-gunpReposCh
+		if !ok {
+			<-scanningDoneCh
 			return scanningDoneMsg{}
-		case gunpRepo, ok := <-gunpReposCh:
-			if !ok {
-				return scanningDoneMsg{}
-			}
-			return scanningProgressMsg{gunpRepo: gunpRepo}
 		}
+		return scanningProgressMsg{gunpRepo: gunpRepo}
 	}
 }
 
@@ -209,7 +300,7 @@ func refreshCmd(m unpushedAppModel) tea.Cmd {
 			// read pump
 			go func() {
 				defer close(scanDoneCh)
-				gunp.RefreshRepos(gitPathsCh, gunpReposCh)
+				gunp.RefreshRepos(gitPathsCh, gunpReposCh, m.opts)
 			}()
 
 			return refreshReposMsg{chDone: scanDoneCh, chPaths: gitPathsCh, chRepos: gunpReposCh}
@@ -222,9 +313,154 @@ func (m unpushedAppModel) getProgressPercent() float64 {
 	if len(m.gitPaths) == 0 {
 		return 0
 	}
+	if m.state == fetching {
+		return float64(len(m.fetched)) / float64(len(m.gitPaths))
+	}
 	return float64(len(m.gunpRepos)) / float64(len(m.gitPaths))
 }
 
+// updateRows fills the table with the repos that have something to show,
+// keeping the cursor on the same repo while rows are added
+func (m *unpushedAppModel) updateRows() {
+	cursorID, cursorErr := getSelectedRow(m.table)
+
+	rows := []table.Row{}
+	unpushedCount := 0
+	indexes := make([]int, 0, len(m.gunpRepos))
+	for i, repo := range m.gunpRepos {
+		unpushedCount += len(repo.UnpushedCommits)
+		if matchFilter(repo.Path, m.filter.Value()) {
+			indexes = append(indexes, i)
+		}
+	}
+	sortRepoIndexes(indexes, m.gunpRepos, m.sortBy, m.sortReverse)
+
+	for _, i := range indexes {
+		repo := m.gunpRepos[i]
+		actionStatus := m.actionStatus[repo.Path]
+		if actionStatus == "" && repo.AuthorErr != nil {
+			actionStatus = "⚠ --mine failed"
+		}
+		if len(repo.UnpushedCommits) > 0 || len(repo.UnpushedTags) > 0 || repo.Stale || repo.FetchErr != nil || repo.AuthorErr != nil || actionStatus != "" {
+			path := repo.Path
+			if m.selected[repo.Path] {
+				path = "✔ " + path
+			}
+			rows = append(rows, table.Row{strconv.Itoa(i), path, uiUnpushedCount(repo), uiOldest(repo), uiLastFetch(repo), actionStatus})
+		}
+	}
+	m.unpushedCount = unpushedCount
+	m.table.SetRows(rows)
+
+	if cursorErr == nil {
+		for i, row := range rows {
+			if row[0] == strconv.Itoa(cursorID) {
+				m.table.SetCursor(i)
+				break
+			}
+		}
+	}
+	if m.table.Cursor() >= len(rows) {
+		m.table.SetCursor(len(rows) - 1)
+	}
+	if selectedRowIdx, err := getSelectedRow(m.table); err == nil {
+		m.cursorRepo = selectedRowIdx
+	} else {
+		m.cursorRepo = 0
+	}
+
+	if m.treeView {
+		m.updateTree()
+	}
+}
+
+// updateTree fills the tree view, keeping the cursor on the same directory
+func (m *unpushedAppModel) updateTree() {
+	var cursorPath string
+	if node := m.treeCursor(); node != nil {
+		cursorPath = node.path
+	}
+
+	var depths []int
+	m.treeNodes, depths = buildTree(m.rootDir, m.gitPaths, m.gunpRepos, m.filter.Value()).flatten(m.collapsed)
+	m.tableTree.SetRows(treeRows(m.treeNodes, depths, m.collapsed, m.selected))
+
+	for i, node := range m.treeNodes {
+		if node.path == cursorPath {
+			m.tableTree.SetCursor(i)
+			break
+		}
+	}
+	if m.tableTree.Cursor() >= len(m.treeNodes) {
+		m.tableTree.SetCursor(len(m.treeNodes) - 1)
+	}
+	m.syncTreeCursor()
+}
+
+// treeCursor returns the node under the cursor of the tree view, nil if none
+func (m unpushedAppModel) treeCursor() *treeNode {
+	cursor := m.tableTree.Cursor()
+	if cursor < 0 || cursor >= len(m.treeNodes) {
+		return nil
+	}
+	return m.treeNodes[cursor]
+}
+
+// syncTreeCursor points the selected repo to the scanned repo under the cursor of the tree view
+func (m *unpushedAppModel) syncTreeCursor() {
+	if node := m.treeCursor(); node != nil && node.repoIdx >= 0 {
+		m.cursorRepo = node.repoIdx
+	}
+}
+
+// replaceRepo swaps a repo with its re-scanned version
+func (m *unpushedAppModel) replaceRepo(gunpRepo *gunp.GunpRepo) {
+	for i, repo := range m.gunpRepos {
+		if repo.Path == gunpRepo.Path {
+			m.gunpRepos[i] = gunpRepo
+		}
+	}
+}
+
+// openDetail shows the unpushed commits of the selected repo
+func (m *unpushedAppModel) openDetail() {
+	m.showDetail = true
+	rows := []table.Row{}
+	selectedRepo := m.gunpRepos[m.cursorRepo]
+	for _, cmt := range selectedRepo.UnpushedCommits {
+		upstream := ""
+		if selectedRepo.IsEquivalent(cmt.Hash) {
+			upstream = "equivalent"
+		}
+		rows = append(rows, table.Row{cmt.Hash.String(), cmt.Author.When.Format(time.RFC1123), cmt.Author.String(), cmt.Message, upstream})
+	}
+	m.tableCommits.SetRows(rows)
+}
+
+// selectedRepos returns the repos of the selection, or the one under the cursor when nothing is selected
+func (m unpushedAppModel) selectedRepos() []*gunp.GunpRepo {
+	var repos []*gunp.GunpRepo
+	for _, repo := range m.gunpRepos {
+		if m.selected[repo.Path] {
+			repos = append(repos, repo)
+		}
+	}
+	if i, ok := m.cursorRepoIdx(); len(repos) == 0 && ok {
+		repos = append(repos, m.gunpRepos[i])
+	}
+	return repos
+}
+
+func (m unpushedAppModel) fetchFailures() int {
+	failures := 0
+	for _, result := range m.fetched {
+		if result.Err != nil {
+			failures++
+		}
+	}
+	return failures
+}
+
 // ------------------------------------------------------------
 // BUBBLETEA FUNCTIONS
 // ------------------------------------------------------------
@@ -235,18 +471,38 @@ func (m unpushedAppModel) Init() tea.Cmd {
 		m.spinner.Tick,
 		counterCmd(m.walkedCounter),
 		discoveryCmd(m.discoveryDoneCh, m.gitPathsCh),
+		fetchingCmd(m.fetchedCh),
 		scanningCmd(m.scanningDoneCh, m.gunpReposCh),
+		loadHistoryCmd(m.rootDir),
 	)
 }
 
 func (m unpushedAppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
-	// always pass msg to the table
+	// always pass msg to the table, unless a dialog is open
 	var tableCmd tea.Cmd
-	if !m.showDetail {
+	if m.confirmPush != nil {
+		// the dialog handles the keys
+	} else if m.logs != nil {
+		m.logs.viewport, tableCmd = m.logs.viewport.Update(msg)
+		cmds = append(cmds, tableCmd)
+	} else if m.diff != nil {
+		m.diff.viewport, tableCmd = m.diff.viewport.Update(msg)
+		cmds = append(cmds, tableCmd)
+	} else if m.filtering {
+		m.filter, tableCmd = m.filter.Update(msg)
+		cmds = append(cmds, tableCmd)
+	} else if !m.showDetail && m.treeView {
+		m.tableTree, tableCmd = m.tableTree.Update(msg)
+		cmds = append(cmds, tableCmd)
+		m.syncTreeCursor()
+	} else if !m.showDetail {
 		m.table, tableCmd = m.table.Update(msg)
 		cmds = append(cmds, tableCmd)
+		if selectedRowIdx, err := getSelectedRow(m.table); err == nil {
+			m.cursorRepo = selectedRowIdx
+		}
 	} else {
 		m.tableCommits, tableCmd = m.tableCommits.Update(msg)
 		cmds = append(cmds, tableCmd)
@@ -256,9 +512,19 @@ func (m unpushedAppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	cmds = append(cmds, stopwatchCmd)
 
 	switch msg := msg.(type) {
+	case tea.MouseMsg:
+		cmds = append(cmds, m.updateMouse(msg))
+
 	case tea.WindowSizeMsg:
 		m.width, m.height = msg.Width, msg.Height
 		m.progress.Width = msg.Width - 4
+		m.help.Width = msg.Width - 4
+		if m.diff != nil {
+			m.diff.setSize(m.diffSize())
+		}
+		if m.logs != nil {
+			m.logs.setSize(m.diffSize())
+		}
 
 	case discoveryProgressMsg:
 		if msg.gitPath != "" {
@@ -269,33 +535,87 @@ func (m unpushedAppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case discoveryDoneMsg:
 		if m.state == loading {
+			if m.opts.Fetch && !m.fetchDone {
+				m.state = fetching
+			} else {
+				m.state = scanning
+			}
+			// the scanned repos are already read since Init
+			cmds = append(cmds, m.progress.SetPercent(m.getProgressPercent()))
+		}
+
+	case fetchingProgressMsg:
+		m.fetched = append(m.fetched, msg.result)
+		cmds = append(cmds, fetchingCmd(m.fetchedCh))
+		cmds = append(cmds, m.progress.SetPercent(m.getProgressPercent()))
+
+	case fetchingDoneMsg:
+		m.fetchDone = true
+		if m.state == fetching {
 			m.state = scanning
-			cmds = append(cmds, scanningCmd(m.scanningDoneCh, m.gunpReposCh))
+			cmds = append(cmds, m.progress.SetPercent(m.getProgressPercent()))
 		}
 
 	case scanningProgressMsg:
 		m.gunpRepos = append(m.gunpRepos, msg.gunpRepo)
-		m.unpushedCount += len(msg.gunpRepo.UnpushedCommits)
+		m.updateRows()
 		cmds = append(cmds, scanningCmd(m.scanningDoneCh, m.gunpReposCh))
 		cmds = append(cmds, m.progress.SetPercent(m.getProgressPercent()))
 
 	case scanningDoneMsg:
-		rows := []table.Row{}
-		unpushedCount := 0
-		for i, repo := range m.gunpRepos {
-			unpushedCount += len(repo.UnpushedCommits)
-			if len(repo.UnpushedCommits) > 0 {
-				rows = append(rows, table.Row{strconv.Itoa(i), repo.Path, strconv.Itoa(len(repo.UnpushedCommits))})
-			}
-		}
-		m.unpushedCount = unpushedCount
-		m.table.SetRows(rows)
-		selectedRowIdx, err := getSelectedRow(m.table)
-		if err == nil {
-			m.cursorRepo = selectedRowIdx
+		if m.state == finished {
+			break
 		}
+		m.updateRows()
 		m.state = finished
 		cmds = append(cmds, m.stopwatch.Stop())
+		if !m.opts.Filtered() {
+			cmds = append(cmds, saveSnapshotCmd(m.rootDir, m.gunpRepos))
+		}
+
+	case historyMsg:
+		m.history = msg.history
+
+	case diffMsg:
+		width, height := m.diffSize()
+		m.diff = newDiffView(msg, width, height, m.keys)
+
+	case pushResultMsg:
+		m.actionStatus[msg.result.Plan.Path] = uiPushResult(msg.result)
+		m.replaceRepo(msg.gunpRepo)
+		m.updateRows()
+
+	case fetchResultMsg:
+		m.actionStatus[msg.result.Path] = uiFetchResult(msg.result)
+		m.replaceRepo(msg.gunpRepo)
+		m.updateRows()
+
+	case logTickMsg:
+		if m.logs != nil {
+			m.logs.refresh()
+			cmds = append(cmds, logTickCmd())
+		}
+
+	case copiedMsg:
+		m.toastID++
+		m.toast = uiCopied(msg)
+		cmds = append(cmds, toastExpiredCmd(m.toastID))
+
+	case toastExpiredMsg:
+		if msg.id == m.toastID {
+			m.toast = ""
+		}
+
+	case execDoneMsg:
+		if msg.err != nil {
+			m.actionStatus[msg.path] = uiExecResult(msg)
+			m.updateRows()
+		}
+		cmds = append(cmds, rescanRepoCmd(msg.path, m.opts))
+
+	case rescanMsg:
+		m.replaceRepo(msg.gunpRepo)
+		m.updateRows()
 
 	case refreshReposMsg:
 		m.state = loading
@@ -318,36 +638,192 @@ func (m unpushedAppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "esc", "q":
+		if key.Matches(msg, m.keys.ForceQuit) {
+			return m, tea.Quit
+		}
+		if m.showHelp {
+			if key.Matches(msg, m.keys.CloseHelp) {
+				m.showHelp = false
+			}
+			break
+		}
+		if m.logs != nil {
+			switch {
+			case key.Matches(msg, m.keys.CloseLogs):
+				m.logs = nil
+			case key.Matches(msg, m.keys.LogLevel):
+				m.logs.nextLevel()
+			case key.Matches(msg, m.keys.Help):
+				m.showHelp = true
+			case key.Matches(msg, m.keys.Quit):
+				return m, tea.Quit
+			}
+			break
+		}
+		if m.diff != nil {
+			switch {
+			case key.Matches(msg, m.keys.Back):
+				m.diff = nil
+			case key.Matches(msg, m.keys.NextFile):
+				m.diff.nextFile()
+			case key.Matches(msg, m.keys.PrevFile):
+				m.diff.prevFile()
+			case key.Matches(msg, m.keys.Help):
+				m.showHelp = true
+			case key.Matches(msg, m.keys.Quit):
+				return m, tea.Quit
+			}
+			break
+		}
+		if m.filtering {
+			switch {
+			case key.Matches(msg, m.keys.ApplyFilter):
+				m.filtering = false
+				m.filter.Blur()
+			case key.Matches(msg, m.keys.ClearFilter):
+				m.filtering = false
+				m.filter.Blur()
+				m.filter.SetValue("")
+			}
+			m.updateRows()
+			break
+		}
+		if m.confirmPush != nil {
+			switch {
+			case key.Matches(msg, m.keys.Confirm):
+				for _, plan := range m.confirmPush.plans {
+					m.actionStatus[plan.Path] = "pushing..."
+					cmds = append(cmds, pushCmd(plan, m.opts))
+				}
+				m.confirmPush = nil
+				m.updateRows()
+			case key.Matches(msg, m.keys.Cancel):
+				m.confirmPush = nil
+			}
+			break
+		}
+		if key.Matches(msg, m.keys.Help) {
+			m.showHelp = true
+			break
+		}
+		if m.showDetail {
+			switch {
+			case key.Matches(msg, m.keys.Diff):
+				commits := m.gunpRepos[m.cursorRepo].UnpushedCommits
+				m.cursorCommit = m.tableCommits.Cursor()
+				if m.cursorCommit >= 0 && m.cursorCommit < len(commits) {
+					cmds = append(cmds, diffCmd(commits[m.cursorCommit]))
+				}
+			case key.Matches(msg, m.keys.CloseDetail):
+				m.showDetail = false
+			case key.Matches(msg, m.keys.CopyHash):
+				commits := m.gunpRepos[m.cursorRepo].UnpushedCommits
+				if cursor := m.tableCommits.Cursor(); cursor >= 0 && cursor < len(commits) {
+					cmds = append(cmds, copyCmd("commit hash", commits[cursor].Hash.String()))
+				}
+			case key.Matches(msg, m.keys.CopySummary):
+				cmds = append(cmds, copyCmd("summary", unpushedSummary(m.gunpRepos)))
+			case key.Matches(msg, m.keys.Quit):
+				return m, tea.Quit
+			}
+			break
+		}
+		if key.Matches(msg, m.keys.Quit) {
 			return m, tea.Quit
 		}
+		if m.treeView && m.updateTreeKey(msg) {
+			break
+		}
+		// the repos already scanned can be browsed while the scan is running
 		switch m.state {
-		case finished:
-			switch msg.String() {
-			case "down", "up", "j", "k":
-				selectedRowIdx, err := getSelectedRow(m.table)
-				if err == nil {
-					m.cursorRepo = selectedRowIdx
+		case loading, fetching, scanning, finished:
+			switch {
+			case key.Matches(msg, m.keys.Detail):
+				if len(m.table.Rows()) == 0 {
+					break
 				}
-			case "enter", "v":
-				if len(m.gitPaths) <= 0 {
+				m.openDetail()
+				cmds = append(cmds, uiUpdateCmd())
+			case key.Matches(msg, m.keys.Select):
+				path, ok := m.cursorRepoPath()
+				if !ok {
 					break
 				}
-				if m.showDetail {
-					m.showDetail = false
+				if m.selected[path] {
+					delete(m.selected, path)
 				} else {
-					m.showDetail = true
-					rows := []table.Row{}
-					for _, cmt := range m.gunpRepos[m.cursorRepo].UnpushedCommits {
-						rows = append(rows, table.Row{cmt.Hash.String(), cmt.Author.When.Format(time.RFC1123), cmt.Author.String(), cmt.Message})
+					m.selected[path] = true
+				}
+				m.updateRows()
+			case key.Matches(msg, m.keys.SelectAll):
+				for _, row := range m.table.Rows() {
+					if i, err := strconv.Atoi(row[0]); err == nil {
+						m.selected[m.gunpRepos[i].Path] = true
 					}
-					m.tableCommits.SetRows(rows)
-					cmds = append(cmds, uiUpdateCmd())
 				}
-			case "r":
+				m.updateRows()
+			case key.Matches(msg, m.keys.SelectNone):
+				m.selected = map[string]bool{}
+				m.updateRows()
+			case key.Matches(msg, m.keys.Push):
+				i, ok := m.cursorRepoIdx()
+				if !ok || m.state != finished {
+					break
+				}
+				m.confirmPush = newPushConfirm([]*gunp.GunpRepo{m.gunpRepos[i]})
+			case key.Matches(msg, m.keys.PushSelected):
+				if m.state != finished {
+					break
+				}
+				m.confirmPush = newPushConfirm(m.selectedRepos())
+			case key.Matches(msg, m.keys.Tree):
+				m.treeView = true
+				m.updateTree()
+			case key.Matches(msg, m.keys.Sort):
+				m.sortBy = m.sortBy.next()
+				m.sortReverse = false
+				m.updateRows()
+			case key.Matches(msg, m.keys.SortReverse):
+				m.sortReverse = !m.sortReverse
+				m.updateRows()
+			case key.Matches(msg, m.keys.Filter):
+				m.filtering = true
+				cmds = append(cmds, m.filter.Focus())
+			case key.Matches(msg, m.keys.Fetch):
+				for _, repo := range m.selectedRepos() {
+					m.actionStatus[repo.Path] = "fetching..."
+					cmds = append(cmds, fetchRepoCmd(repo.Path, m.opts))
+				}
+				m.updateRows()
+			case key.Matches(msg, m.keys.Logs):
+				width, height := m.diffSize()
+				m.logs = newLogPanel(width, height, m.keys)
+				cmds = append(cmds, logTickCmd())
+			case key.Matches(msg, m.keys.CopyPath):
+				if path, ok := m.cursorRepoPath(); ok {
+					cmds = append(cmds, copyCmd("path", path))
+				}
+			case key.Matches(msg, m.keys.CopySummary):
+				cmds = append(cmds, copyCmd("summary", unpushedSummary(m.gunpRepos)))
+			case key.Matches(msg, m.keys.Editor):
+				if path, ok := m.cursorRepoPath(); ok {
+					cmds = append(cmds, execCmd("editor", editorCommand(), path))
+				}
+			case key.Matches(msg, m.keys.Shell):
+				if path, ok := m.cursorRepoPath(); ok {
+					cmds = append(cmds, execCmd("shell", shellCommand(), path))
+				}
+			case key.Matches(msg, m.keys.Open):
+				if path, ok := m.cursorRepoPath(); ok {
+					cmds = append(cmds, execCmd("open", openerCommand(m.opener), path))
+				}
+			case key.Matches(msg, m.keys.Refresh):
+				if m.state != finished {
+					break
+				}
 				m.showDetail = false
 				m.gunpRepos = []*gunp.GunpRepo{}
+				m.updateRows()
 				cmds = append(cmds, refreshCmd(m))
 			}
 		}
@@ -378,23 +854,32 @@ func (m unpushedAppModel) View() string {
 	// - longest column title
 	// - max width is container width / number of columns
 	m.table.SetColumns(updateWidthColumns(m.table, m.width))
+	m.tableTree.SetColumns(updateWidthColumns(m.tableTree, m.width))
 	m.tableCommits.SetColumns(updateWidthColumns(m.tableCommits, m.width))
 
 	switch m.state {
-	case loading:
-		// show progress and text
+	case loading, fetching, scanning:
+		// show progress and text, with the results so far
 		content = lipgloss.JoinVertical(
 			lipgloss.Center,
 			m.uiTitle(),
 			"",
 			m.progress.View(),
 		)
-	case scanning:
+		if len(m.table.Rows()) > 0 || m.treeView {
+			content = lipgloss.JoinVertical(
+				lipgloss.Center,
+				content,
+				"",
+				m.uiTable(),
+				m.uiStatusLine(),
+			)
+		}
 		content = lipgloss.JoinVertical(
 			lipgloss.Center,
-			m.uiTitle(),
-			"",
-			m.progress.View(),
+			content,
+			"\n",
+			m.uiHelpText(),
 		)
 	case finished:
 		// show the table with results
@@ -403,7 +888,8 @@ func (m unpushedAppModel) View() string {
 			m.uiTitle(),
 			"",
 			m.uiTable(),
-			"\n\n\n",
+			m.uiStatusLine(),
+			"\n\n",
 			m.uiHelpText(),
 		)
 	}
@@ -425,9 +911,54 @@ func (m unpushedAppModel) uiTitle() string {
 	titleWalked := fmt.Sprintf("Walked Directories: %d", m.walkedCounter.Get())
 	titleDiscovery := fmt.Sprintf("👀 Discovering Repositories... %d", len(m.gitPaths))
 	titleDiscoveryDone := fmt.Sprintf("👀 Repository Discovered: %d", len(m.gitPaths))
+	titleFetching := fmt.Sprintf("📡 Fetching Repositories... (%d/%d)", len(m.fetched), len(m.gitPaths))
+	titleFetchingDone := fmt.Sprintf("📡 Repository Fetched: %d", len(m.fetched))
+	if failures := m.fetchFailures(); failures > 0 {
+		failed := StaleStyle().Render(fmt.Sprintf(" (%d failed)", failures))
+		titleFetching += failed
+		titleFetchingDone += failed
+	}
 	titleScanning := fmt.Sprintf("🔍 Scanning Repositories... (%d/%d)", len(m.gunpRepos), len(m.gitPaths))
 	titleScanningDone := fmt.Sprintf("🔎 Repository Scanned: %d", len(m.gunpRepos))
 	titleUnpushed := fmt.Sprintf("🐙 Unpushed Commits: %d", m.unpushedCount)
+	if m.opts.Author != nil {
+		titleUnpushed += fmt.Sprintf(" (author: %s)", m.opts.Author)
+	} else if m.opts.Mine {
+		titleUnpushed += " (author: mine)"
+	}
+	if m.opts.Since != nil {
+		titleUnpushed += fmt.Sprintf(" (since: %s)", m.opts.Since.Format("2006-01-02 15:04"))
+	}
+	if m.opts.Until != nil {
+		titleUnpushed += fmt.Sprintf(" (until: %s)", m.opts.Until.Format("2006-01-02 15:04"))
+	}
+	if sparkline := m.uiSparkline(); sparkline != "" && !m.opts.Filtered() {
+		titleUnpushed += "  " + sparkline
+	}
+	if m.opts.StaleAfter > 0 {
+		stale := 0
+		for _, repo := range m.gunpRepos {
+			if repo.Stale {
+				stale++
+			}
+		}
+		if stale > 0 {
+			titleUnpushed += "\n" + StaleStyle().Render(fmt.Sprintf("⚠ Not fetched for more than %s: %d (results may be unreliable)", m.opts.StaleAfter, stale))
+		}
+	}
+
+	if m.opts.Fetch {
+		switch m.state {
+		case loading:
+			return fmt.Sprintf("%s%s\n%s\n%s %s\n%s %s\n%s %s\n%s", titleGunp, m.uiStopwatch(), titleWalked, m.uiSpinner(), titleDiscovery, m.uiSpinner(), titleFetching, m.uiSpinner(), titleScanning, titleUnpushed)
+		case fetching:
+			return fmt.Sprintf("%s%s\n%s\n%s\n%s %s\n%s %s\n%s", titleGunp, m.uiStopwatch(), titleWalked, titleDiscoveryDone, m.uiSpinner(), titleFetching, m.uiSpinner(), titleScanning, titleUnpushed)
+		case scanning:
+			return fmt.Sprintf("%s%s\n%s\n%s\n%s\n%s %s\n%s", titleGunp, m.uiStopwatch(), titleWalked, titleDiscoveryDone, titleFetchingDone, m.uiSpinner(), titleScanning, titleUnpushed)
+		case finished:
+			return fmt.Sprintf("%s%s\n%s\n%s\n%s\n%s\n%s", titleGunp, m.uiStopwatch(), titleWalked, titleDiscoveryDone, titleFetchingDone, titleScanningDone, titleUnpushed)
+		}
+	}
 
 	switch m.state {
 	case loading:
@@ -440,16 +971,111 @@ func (m unpushedAppModel) uiTitle() string {
 	return ""
 }
 
+// uiHelpText is the footer hint, built from the same key bindings of the help overlay
 func (m unpushedAppModel) uiHelpText() string {
-	switch m.state {
-	case loading:
-		return "Press 'q' to quit, 'h' for help"
-	case scanning:
-		return "Press 'q' to quit, 'h' for help"
-	case finished:
-		return "Press 'q' to quit, 'j'/'k'/'up'/'down' to navigate, 'r' to refresh, 'v'/'enter' to toggle detail"
+	return m.help.ShortHelpView(m.helpKeys().ShortHelp())
+}
+
+// uiHelp is the help overlay with every key binding available right now
+func (m unpushedAppModel) uiHelp() string {
+	return TableWrapperStyle().Render(lipgloss.JoinVertical(
+		lipgloss.Left,
+		"Help",
+		"",
+		m.help.FullHelpView(m.helpKeys().FullHelp()),
+		"",
+		m.help.ShortHelpView([]key.Binding{m.keys.CloseHelp}),
+	))
+}
+
+func uiUnpushedCount(repo *gunp.GunpRepo) string {
+	count := strconv.Itoa(len(repo.UnpushedCommits))
+	equivalent := 0
+	for _, cmt := range repo.UnpushedCommits {
+		if repo.IsEquivalent(cmt.Hash) {
+			equivalent++
+		}
 	}
-	return ""
+	if equivalent > 0 {
+		count = fmt.Sprintf("%s (%d equivalent upstream)", count, equivalent)
+	}
+	if len(repo.UnpushedTags) > 0 {
+		count = fmt.Sprintf("%s + %d tags", count, len(repo.UnpushedTags))
+	}
+	return count
+}
+
+// uiOldest is the age of the oldest unpushed commit, see uiOldestStyle for its color
+func uiOldest(repo *gunp.GunpRepo) string {
+	if repo.OldestUnpushed.IsZero() {
+		return ""
+	}
+	return humanizeSince(repo.OldestUnpushed)
+}
+
+// uiOldestStyle colors the age of the oldest unpushed commit by how urgent it is to push
+func uiOldestStyle(repo *gunp.GunpRepo) (lipgloss.Style, bool) {
+	if repo.OldestUnpushed.IsZero() {
+		return lipgloss.Style{}, false
+	}
+	return AgeStyle(time.Since(repo.OldestUnpushed)), true
+}
+
+// repoCellStyle colors the cells of the repository table, see styleCells
+func (m unpushedAppModel) repoCellStyle(row table.Row, col int) (lipgloss.Style, bool) {
+	i, err := strconv.Atoi(row[0])
+	if err != nil || i < 0 || i >= len(m.gunpRepos) {
+		return lipgloss.Style{}, false
+	}
+	switch col {
+	case repoColumnOldest:
+		return uiOldestStyle(m.gunpRepos[i])
+	case repoColumnLastFetch:
+		return uiLastFetchStyle(m.gunpRepos[i])
+	}
+	return lipgloss.Style{}, false
+}
+
+// uiLastFetch is when the repo was last fetched, flagged when stale, see uiLastFetchStyle for its color
+func uiLastFetch(repo *gunp.GunpRepo) string {
+	if repo.FetchErr != nil {
+		return "⚠ fetch failed"
+	}
+	if repo.NoRemote {
+		return "no remote"
+	}
+	lastFetch := humanizeSince(repo.LastFetch)
+	if repo.Stale {
+		return "⚠ " + lastFetch
+	}
+	return lastFetch
+}
+
+// uiLastFetchStyle colors the last fetch of the repos that are stale or failed to fetch
+func uiLastFetchStyle(repo *gunp.GunpRepo) (lipgloss.Style, bool) {
+	if repo.FetchErr != nil || repo.Stale {
+		return StaleStyle(), true
+	}
+	return lipgloss.Style{}, false
+}
+
+// uiDetailLastFetch is uiLastFetch colored, outside of the table
+func uiDetailLastFetch(repo *gunp.GunpRepo) string {
+	if style, ok := uiLastFetchStyle(repo); ok {
+		return style.Render(uiLastFetch(repo))
+	}
+	return uiLastFetch(repo)
+}
+
+func (m unpushedAppModel) uiStatusLine() string {
+	var parts []string
+	if len(m.selected) > 0 {
+		parts = append(parts, fmt.Sprintf("✔ %d selected", len(m.selected)))
+	}
+	if m.toast != "" {
+		parts = append(parts, m.toast)
+	}
+	return strings.Join(parts, "   ")
 }
 
 func (m unpushedAppModel) uiStopwatch() string {
@@ -465,17 +1091,78 @@ func (m unpushedAppModel) uiSpinner() string {
 }
 
 func (m unpushedAppModel) uiTable() string {
-	m.table.SetStyles(TableStyle())
-	return TableWrapperStyle().Render(m.table.View())
+	if m.treeView {
+		m.tableTree.SetStyles(m.tableStyles(!m.showDetail))
+		return TableWrapperStyle().Render(lipgloss.JoinVertical(lipgloss.Left, m.uiTableHeader(), "", m.tableTree.View()))
+	}
+	styles := m.tableStyles(!m.showDetail)
+	m.table.SetStyles(styles)
+	tableView := m.table.View()
+	if m.hitTest == hitNone {
+		tableView = styleCells(m.table, styles, tableView, m.repoCellStyle)
+	}
+	return TableWrapperStyle().Render(lipgloss.JoinVertical(lipgloss.Left, m.uiTableHeader(), "", tableView))
+}
+
+// uiTableHeader shows the active sort and filter
+func (m unpushedAppModel) uiTableHeader() string {
+	direction := "↓"
+	if m.sortReverse {
+		direction = "↑"
+	}
+	header := fmt.Sprintf("Sort: %s %s", m.sortBy, direction)
+	if m.treeView {
+		header = "Tree view"
+	}
+	if m.filtering {
+		header = fmt.Sprintf("%s · Filter: %s", header, m.filter.View())
+	} else if m.filter.Value() != "" {
+		header = fmt.Sprintf("%s · Filter: %s (%d shown)", header, m.filter.Value(), len(m.table.Rows()))
+	}
+	return header
+}
+
+func (m unpushedAppModel) diffSize() (int, int) {
+	return m.width - 8, m.height - 10
 }
 
 func (m unpushedAppModel) uiOverlay(content string) string {
+	if m.showHelp {
+		// the help is about the view below it, only the help is hit-tested
+		below := m
+		below.showHelp = false
+		below.hitTest = hitNone
+		content = below.uiOverlay(content)
+		return overlay.Composite(m.hitBlock(m.uiHelp()), content, overlay.Center, overlay.Center, 0.0, 0.0)
+	}
+	if m.logs != nil {
+		return overlay.Composite(m.hitBlock(m.logs.View(m.uiHelpText())), content, overlay.Center, overlay.Center, 0.0, 0.0)
+	}
+	if m.diff != nil {
+		return overlay.Composite(m.hitBlock(m.diff.View(m.uiHelpText())), content, overlay.Center, overlay.Center, 0.0, 0.0)
+	}
+	if m.confirmPush != nil {
+		return overlay.Composite(m.hitBlock(m.uiPushConfirm()), content, overlay.Center, overlay.Center, 0.0, 0.0)
+	}
 	if m.showDetail {
 		selectedRepo := m.gunpRepos[m.cursorRepo]
-		m.tableCommits.SetStyles(TableStyle())
-		detailContent := fmt.Sprintf("Path: %s\nUnpushed Commits: %d\n%s", selectedRepo.Path, len(selectedRepo.UnpushedCommits), TableWrapperStyle().Render(m.tableCommits.View()))
+		m.tableCommits.SetStyles(m.tableStyles(true))
+		detailContent := fmt.Sprintf("Path: %s\nUnpushed Commits: %d\nLast Fetch: %s\n%s", selectedRepo.Path, len(selectedRepo.UnpushedCommits), uiDetailLastFetch(selectedRepo), TableWrapperStyle().Render(m.tableCommits.View()))
+		if !selectedRepo.OldestUnpushed.IsZero() {
+			detailContent = fmt.Sprintf("%s\nOldest unpushed: %s, newest: %s", detailContent, AgeStyle(time.Since(selectedRepo.OldestUnpushed)).Render(uiOldest(selectedRepo)), humanizeSince(selectedRepo.NewestUnpushed))
+		}
+		if selectedRepo.FetchErr != nil {
+			detailContent = fmt.Sprintf("%s\n%s", detailContent, StaleStyle().Render("Fetch failed: "+selectedRepo.FetchErr.Error()))
+		}
+		if selectedRepo.AuthorErr != nil {
+			detailContent = fmt.Sprintf("%s\n%s", detailContent, StaleStyle().Render("--mine: "+selectedRepo.AuthorErr.Error()))
+		}
+		if len(selectedRepo.UnpushedTags) > 0 {
+			detailContent = fmt.Sprintf("%s\nTags not on remote: %s", detailContent, strings.Join(selectedRepo.UnpushedTags, ", "))
+		}
+		detailContent = fmt.Sprintf("%s\n%s", detailContent, m.uiHelpText())
 		detailView := TableWrapperStyle().Render(detailContent)
-		return overlay.Composite(detailView, content, overlay.Center, overlay.Center, 0.0, 0.0)
+		return overlay.Composite(m.hitBlock(detailView), content, overlay.Center, overlay.Center, 0.0, 0.0)
 	}
 	return content
 }
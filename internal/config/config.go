@@ -0,0 +1,75 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Config is the content of the config file of gunp, every section is optional
+type Config struct {
+	Keys Keys `json:"keys"`
+	// Theme is "auto" (the default), "dark", "light", "high-contrast" or one of Themes
+	Theme  string           `json:"theme"`
+	Themes map[string]Theme `json:"themes"`
+	// Opener opens a repo directory, e.g. "code" or "nautilus", the file manager of the OS by default
+	Opener string `json:"opener"`
+}
+
+// Keys remaps the actions of the TUI.
+// Preset is one of "default", "vim" and "emacs", Bindings overrides the keys of single actions on top of it,
+// e.g. {"push": ["p", "ctrl+p"]}
+type Keys struct {
+	Preset   string              `json:"preset"`
+	Bindings map[string][]string `json:"bindings"`
+}
+
+// Theme holds the colors of the TUI, as ANSI codes ("57") or hex ("#5A56E0").
+// A user-defined theme starts from the built-in Base theme ("dark" by default) and overrides the colors set.
+type Theme struct {
+	Base               string `json:"base,omitempty"`
+	Border             string `json:"border,omitempty"`
+	SelectedForeground string `json:"selected_foreground,omitempty"`
+	SelectedBackground string `json:"selected_background,omitempty"`
+	Accent             string `json:"accent,omitempty"`
+	Warning            string `json:"warning,omitempty"`
+	DiffHunk           string `json:"diff_hunk,omitempty"`
+	DiffAdd            string `json:"diff_add,omitempty"`
+	DiffDelete         string `json:"diff_delete,omitempty"`
+	AgeFresh           string `json:"age_fresh,omitempty"`
+	AgeAging           string `json:"age_aging,omitempty"`
+	AgeOld             string `json:"age_old,omitempty"`
+	ProgressFrom       string `json:"progress_from,omitempty"`
+	ProgressTo         string `json:"progress_to,omitempty"`
+}
+
+// DefaultPath is the config file used when --config is not given: $XDG_CONFIG_HOME/gunp/config.json
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gunp", "config.json")
+}
+
+// Load reads the config file at path, a missing file is an empty config
+func Load(path string) (Config, error) {
+	var cfg Config
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,54 @@
+package gunp
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/config"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+// AuthorPattern compiles the --author filter: a regex matched against "Name <email>", like `git log --author`.
+func AuthorPattern(author string) (*regexp.Regexp, error) {
+	if author == "" {
+		return nil, nil
+	}
+	return regexp.Compile(author)
+}
+
+// MinePattern is the author filter of Options.Mine for a repo: its user.email, see UserEmail
+func MinePattern(r *git.Repository) (*regexp.Regexp, error) {
+	email, err := UserEmail(r)
+	if err != nil {
+		return nil, err
+	}
+	return regexp.Compile("<" + regexp.QuoteMeta(email) + ">")
+}
+
+// UserEmail returns the user.email of the repo like git: from its own config first, then the global and the system ones
+func UserEmail(r *git.Repository) (string, error) {
+	cfg, err := r.ConfigScoped(config.SystemScope)
+	if err != nil {
+		return "", fmt.Errorf("load the git config: %w", err)
+	}
+	if cfg.User.Email == "" {
+		return "", errors.New("user.email is not set in the config of the repo, nor in the global or system git config")
+	}
+	return cfg.User.Email, nil
+}
+
+// FilterByAuthor keeps the commits whose author matches, all of them with a nil pattern
+func FilterByAuthor(commits []*object.Commit, author *regexp.Regexp) []*object.Commit {
+	if author == nil {
+		return commits
+	}
+	filtered := []*object.Commit{}
+	for _, c := range commits {
+		if author.MatchString(fmt.Sprintf("%s <%s>", c.Author.Name, c.Author.Email)) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
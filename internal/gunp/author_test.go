@@ -0,0 +1,50 @@
+package gunp
+
+import (
+	"testing"
+)
+
+func TestGitStatsMineRepoEmail(t *testing.T) {
+	// no global config, the email comes from the config of the repo
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+
+	tr := newTestRepo(t)
+	tr.commit("base-1")
+	tr.setRef("refs/remotes/origin/"+tr.branch(), tr.head())
+	tr.commit("test-1")
+
+	opts := Options{Mine: true}
+	stats := GitStats(tr.dir, opts)
+	if stats.AuthorErr == nil {
+		t.Fatalf("no error without user.email, got %d commits", len(stats.UnpushedCommits))
+	}
+	if len(stats.UnpushedCommits) != 0 {
+		t.Errorf("got %d unpushed commits without user.email, want none", len(stats.UnpushedCommits))
+	}
+
+	cfg, err := tr.repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.User.Email = "test@example.com"
+	if err := tr.repo.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	stats = GitStats(tr.dir, opts)
+	if stats.AuthorErr != nil {
+		t.Fatal(stats.AuthorErr)
+	}
+	if len(stats.UnpushedCommits) != 1 {
+		t.Errorf("got %d unpushed commits of test@example.com, want 1", len(stats.UnpushedCommits))
+	}
+
+	cfg.User.Email = "other@example.com"
+	if err := tr.repo.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if stats = GitStats(tr.dir, opts); len(stats.UnpushedCommits) != 0 {
+		t.Errorf("got %d unpushed commits of other@example.com, want none", len(stats.UnpushedCommits))
+	}
+}
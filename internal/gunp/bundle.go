@@ -0,0 +1,475 @@
+package gunp
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	logger "gunp/internal/log"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/format/packfile"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/go-git/go-git/v6/plumbing/revlist"
+)
+
+// ManifestFile is the name of the manifest written next to the bundles
+const ManifestFile = "manifest.json"
+
+const (
+	manifestVersion = 1
+	bundleSignature = "# v2 git bundle\n"
+	// bundlePackWindow is the delta window of the packs, like git pack-objects
+	bundlePackWindow = 10
+)
+
+// BundleManifest maps the bundles written by Bundle to the repos and branches they were made from.
+type BundleManifest struct {
+	Version   int           `json:"version"`
+	CreatedAt time.Time     `json:"createdAt"`
+	RootDir   string        `json:"rootDir"`
+	Bundles   []BundleEntry `json:"bundles"`
+}
+
+// BundleEntry is the bundle of the unpushed branches of a repo.
+type BundleEntry struct {
+	// File is the path of the bundle, relative to the manifest
+	File string `json:"file"`
+	// Path is where the repo was when it was bundled
+	Path string `json:"path"`
+	// Remotes maps the remotes of the repo to their URL, to clone it again
+	Remotes  map[string]string `json:"remotes,omitempty"`
+	Branches []BundleBranch    `json:"branches"`
+	SHA256   string            `json:"sha256"`
+}
+
+// BundleBranch is an unpushed branch, its commits down to the prerequisites are in the bundle.
+type BundleBranch struct {
+	// Name is the full name of the branch, e.g. refs/heads/main
+	Name string `json:"name"`
+	Hash string `json:"hash"`
+	// Upstream is the remote-tracking branch the unpushed commits are counted from,
+	// empty for a branch that was never pushed, counted from all the remote-tracking branches
+	Upstream string `json:"upstream,omitempty"`
+	// Prerequisites are the merge bases with the upstream, the bundle needs them in the repo it is unbundled into
+	Prerequisites []string `json:"prerequisites,omitempty"`
+	Commits       int      `json:"commits"`
+}
+
+// BundleCheck is the result of the verification of a bundle.
+type BundleCheck struct {
+	Entry BundleEntry
+	// MissingRepo is true when the original repo is not there anymore to check the prerequisites
+	MissingRepo bool
+	Err         error
+}
+
+// bundleHeader is what precedes the pack in a bundle
+type bundleHeader struct {
+	Prerequisites []plumbing.Hash
+	Refs          map[plumbing.ReferenceName]plumbing.Hash
+}
+
+// Bundle writes into dest a git bundle of the unpushed branches of every repo under the current folder,
+// and the manifest of the bundles. A repo that fails is left out and its error is returned along the others.
+func Bundle(dest string) (BundleManifest, error) {
+	rootDir := rootCwd()
+	manifest := BundleManifest{
+		Version:   manifestVersion,
+		CreatedAt: time.Now(),
+		RootDir:   rootDir,
+		Bundles:   []BundleEntry{},
+	}
+	gitDirs, err := gitPathsOutside(rootDir, dest)
+	if err != nil {
+		return manifest, err
+	}
+
+	var errs []error
+	for _, gitDir := range gitDirs {
+		entry, err := bundleRepo(rootDir, gitDir, dest)
+		if err != nil {
+			logger.Get().Error("bundle", "gitDir", gitDir, "err", err)
+			errs = append(errs, fmt.Errorf("%s: %w", gitDir, err))
+			continue
+		}
+		if entry != nil {
+			manifest.Bundles = append(manifest.Bundles, *entry)
+		}
+	}
+
+	if err := writeManifest(filepath.Join(dest, ManifestFile), manifest); err != nil {
+		errs = append(errs, err)
+	}
+	return manifest, errors.Join(errs...)
+}
+
+// bundleRepo writes the bundle of the unpushed branches of a repo, nil if there are none
+func bundleRepo(rootDir, gitDir, dest string) (*BundleEntry, error) {
+	r, err := git.PlainOpen(gitDir)
+	if err != nil {
+		return nil, err
+	}
+	branches, err := unpushedBranches(r)
+	if err != nil || len(branches) == 0 {
+		return nil, err
+	}
+
+	file := backupName(rootDir, gitDir) + ".bundle"
+	entry := &BundleEntry{
+		File:     filepath.ToSlash(file),
+		Path:     gitDir,
+		Remotes:  remoteURLs(r),
+		Branches: branches,
+	}
+
+	bundlePath := filepath.Join(dest, file)
+	if err := os.MkdirAll(filepath.Dir(bundlePath), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if err := writeBundle(r, io.MultiWriter(f, hash), branches); err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	entry.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	logger.Get().Info("bundle", "gitDir", gitDir, "file", bundlePath, "branches", len(branches))
+	return entry, nil
+}
+
+// gitPathsOutside lists the repos under rootDir, except the ones in dest where a backup is written
+func gitPathsOutside(rootDir, dest string) ([]string, error) {
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return nil, err
+	}
+	absDest, err := filepath.Abs(dest)
+	if err != nil {
+		return nil, err
+	}
+	var gitDirs []string
+	for _, gitDir := range gitPathsPlain(rootDir) {
+		if !strings.HasPrefix(gitDir+string(filepath.Separator), absDest+string(filepath.Separator)) {
+			gitDirs = append(gitDirs, gitDir)
+		}
+	}
+	return gitDirs, nil
+}
+
+// backupName is the path of the backup of a repo in the dest folder: its path relative to rootDir,
+// or the name of rootDir when it is the repo
+func backupName(rootDir, gitDir string) string {
+	name, err := filepath.Rel(rootDir, gitDir)
+	if err != nil || name == "." {
+		return filepath.Base(gitDir)
+	}
+	return name
+}
+
+// unpushedBranches lists the local branches ahead of their upstream, see branchUpstreams.
+func unpushedBranches(r *git.Repository) ([]BundleBranch, error) {
+	iter, err := r.Branches()
+	if err != nil {
+		return nil, err
+	}
+	var branches []BundleBranch
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		tip, err := r.CommitObject(ref.Hash())
+		if err != nil {
+			return fmt.Errorf("%s: %w", ref.Name(), err)
+		}
+		branch := BundleBranch{Name: ref.Name().String(), Hash: ref.Hash().String()}
+
+		upstreams, tracked, err := branchUpstreams(r, ref)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ref.Name(), err)
+		}
+		if tracked {
+			branch.Upstream = upstreams[0].Name().String()
+		}
+		seen := map[plumbing.Hash]bool{}
+		for _, upstream := range upstreams {
+			upstreamCommit, err := r.CommitObject(upstream.Hash())
+			if err != nil {
+				return fmt.Errorf("%s: %w", upstream.Name(), err)
+			}
+			bases, err := tip.MergeBase(upstreamCommit)
+			if err != nil {
+				return fmt.Errorf("%s: %w", ref.Name(), err)
+			}
+			for _, base := range bases {
+				if base.Hash == tip.Hash {
+					// nothing unpushed, the branch is in the upstream
+					return nil
+				}
+				if !seen[base.Hash] {
+					seen[base.Hash] = true
+					branch.Prerequisites = append(branch.Prerequisites, base.Hash.String())
+				}
+			}
+		}
+
+		commits, err := branchCommits(r, tip, branch.Prerequisites)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ref.Name(), err)
+		}
+		branch.Commits = len(commits)
+		branches = append(branches, branch)
+		return nil
+	})
+	return branches, err
+}
+
+// branchCommits lists the commits of a branch that are not reachable from its prerequisites, newest first.
+// The history of the prerequisites is excluded as a whole, not only the merge bases,
+// a branch that merged its upstream would otherwise list the pushed commits behind the merge.
+func branchCommits(r *git.Repository, tip *object.Commit, prerequisites []string) ([]*object.Commit, error) {
+	pushed := map[plumbing.Hash]bool{}
+	for _, prerequisite := range prerequisites {
+		base, err := r.CommitObject(plumbing.NewHash(prerequisite))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", prerequisite, err)
+		}
+		err = object.NewCommitPreorderIter(base, pushed, nil).ForEach(func(c *object.Commit) error {
+			pushed[c.Hash] = true
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	var commits []*object.Commit
+	err := object.NewCommitPreorderIter(tip, pushed, nil).ForEach(func(c *object.Commit) error {
+		commits = append(commits, c)
+		return nil
+	})
+	return commits, err
+}
+
+// branchUpstreams resolves the remote-tracking branch of a local branch (see upstreamRef),
+// or all the remote-tracking branches for the branches that were never pushed:
+// their unpushed commits are the ones on none of the remotes.
+// It returns none without remotes, the whole history of the branch is unpushed then.
+func branchUpstreams(r *git.Repository, branch *plumbing.Reference) ([]*plumbing.Reference, bool, error) {
+	if upstream, err := upstreamRef(r, branch); err == nil {
+		return []*plumbing.Reference{upstream}, true, nil
+	}
+	refs, err := r.References()
+	if err != nil {
+		return nil, false, err
+	}
+	var upstreams []*plumbing.Reference
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name().IsRemote() && ref.Type() == plumbing.HashReference {
+			upstreams = append(upstreams, ref)
+		}
+		return nil
+	})
+	return upstreams, false, err
+}
+
+// remoteURLs maps the remotes of a repo to their first URL
+func remoteURLs(r *git.Repository) map[string]string {
+	remotes, err := r.Remotes()
+	if err != nil {
+		return nil
+	}
+	urls := map[string]string{}
+	for _, remote := range remotes {
+		if cfg := remote.Config(); len(cfg.URLs) > 0 {
+			urls[cfg.Name] = cfg.URLs[0]
+		}
+	}
+	return urls
+}
+
+// writeBundle writes a v2 git bundle (see gitformat-bundle) of the branches, readable by `git clone` and `git fetch`
+func writeBundle(r *git.Repository, w io.Writer, branches []BundleBranch) error {
+	var header bytes.Buffer
+	header.WriteString(bundleSignature)
+
+	var tips, prerequisites []plumbing.Hash
+	for _, branch := range branches {
+		for _, prerequisite := range branch.Prerequisites {
+			hash := plumbing.NewHash(prerequisite)
+			if slices.Contains(prerequisites, hash) {
+				continue
+			}
+			prerequisites = append(prerequisites, hash)
+			subject := ""
+			if c, err := r.CommitObject(hash); err == nil {
+				subject, _, _ = strings.Cut(c.Message, "\n")
+			}
+			fmt.Fprintf(&header, "-%s %s\n", hash, subject)
+		}
+	}
+	for _, branch := range branches {
+		tips = append(tips, plumbing.NewHash(branch.Hash))
+		fmt.Fprintf(&header, "%s %s\n", branch.Hash, branch.Name)
+	}
+	header.WriteString("\n")
+
+	objects, err := revlist.Objects(r.Storer, tips, prerequisites)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return err
+	}
+	_, err = packfile.NewEncoder(w, r.Storer, false).Encode(objects, bundlePackWindow)
+	return err
+}
+
+// readBundleHeader reads the header of a v2 git bundle, the reader is left at the start of the pack
+func readBundleHeader(r *bufio.Reader) (bundleHeader, error) {
+	header := bundleHeader{Refs: map[plumbing.ReferenceName]plumbing.Hash{}}
+	signature, err := r.ReadString('\n')
+	if err != nil || signature != bundleSignature {
+		return header, errors.New("not a v2 git bundle")
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return header, fmt.Errorf("truncated bundle header: %w", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return header, nil
+		}
+		if prerequisite, ok := strings.CutPrefix(line, "-"); ok {
+			hash, _, _ := strings.Cut(prerequisite, " ")
+			header.Prerequisites = append(header.Prerequisites, plumbing.NewHash(hash))
+			continue
+		}
+		hash, name, ok := strings.Cut(line, " ")
+		if !ok {
+			return header, fmt.Errorf("invalid bundle ref %q", line)
+		}
+		header.Refs[plumbing.ReferenceName(name)] = plumbing.NewHash(hash)
+	}
+}
+
+func writeManifest(path string, manifest BundleManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// ReadManifest reads the manifest of the bundles in dir.
+func ReadManifest(dir string) (BundleManifest, error) {
+	var manifest BundleManifest
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return manifest, err
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("%s: %w", ManifestFile, err)
+	}
+	if manifest.Version != manifestVersion {
+		return manifest, fmt.Errorf("%s: unsupported version %d", ManifestFile, manifest.Version)
+	}
+	return manifest, nil
+}
+
+// VerifyBundles checks the bundles of the manifest in dir, like `git bundle verify`:
+// their checksum, their refs against the manifest, their pack,
+// and that the prerequisites are in the original repo when it is still there.
+func VerifyBundles(dir string) ([]BundleCheck, error) {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	var checks []BundleCheck
+	for _, entry := range manifest.Bundles {
+		check := BundleCheck{Entry: entry}
+		check.MissingRepo, check.Err = verifyBundle(filepath.Join(dir, filepath.FromSlash(entry.File)), entry)
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+func verifyBundle(path string, entry BundleEntry) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return false, err
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != entry.SHA256 {
+		return false, fmt.Errorf("checksum mismatch: %s, expected %s", sum, entry.SHA256)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+
+	reader := bufio.NewReader(f)
+	header, err := readBundleHeader(reader)
+	if err != nil {
+		return false, err
+	}
+	for _, branch := range entry.Branches {
+		if hash, ok := header.Refs[plumbing.ReferenceName(branch.Name)]; !ok || hash.String() != branch.Hash {
+			return false, fmt.Errorf("%s: not in the bundle at %s", branch.Name, branch.Hash)
+		}
+	}
+	if len(header.Refs) != len(entry.Branches) {
+		return false, fmt.Errorf("%d refs in the bundle, %d in the manifest", len(header.Refs), len(entry.Branches))
+	}
+	if err := verifyPack(reader); err != nil {
+		return false, fmt.Errorf("pack: %w", err)
+	}
+
+	r, err := git.PlainOpen(entry.Path)
+	if err != nil {
+		return true, nil
+	}
+	for _, prerequisite := range header.Prerequisites {
+		if _, err := r.Storer.EncodedObject(plumbing.CommitObject, prerequisite); err != nil {
+			if errors.Is(err, plumbing.ErrObjectNotFound) {
+				return false, fmt.Errorf("%s lacks the prerequisite %s", entry.Path, prerequisite)
+			}
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// verifyPack reads a whole pack, inflating its objects and checking its checksum.
+// packfile.Parser is not used since it drops the errors of its scanner.
+func verifyPack(r io.Reader) error {
+	scanner := packfile.NewScanner(r)
+	footer := false
+	for scanner.Scan() {
+		footer = scanner.Data().Section == packfile.FooterSection
+	}
+	if err := scanner.Error(); err != nil {
+		return err
+	}
+	if !footer {
+		return errors.New("truncated pack")
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package gunp
+
+import (
+	"testing"
+)
+
+func TestUnpushedBranchesMergedUpstream(t *testing.T) {
+	tr := mergedUpstreamRepo(t)
+	branches, err := unpushedBranches(tr.repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(branches) != 1 {
+		t.Fatalf("got %d unpushed branches, want feature only: %+v", len(branches), branches)
+	}
+	feature := branches[0]
+	if feature.Name != "refs/heads/feature" || feature.Upstream != "refs/remotes/origin/main" {
+		t.Errorf("got %s tracking %s", feature.Name, feature.Upstream)
+	}
+	// feature-1, the merge and feature-2, not the pushed base-* behind the merge
+	if feature.Commits != 3 {
+		t.Errorf("got %d commits, want 3", feature.Commits)
+	}
+}
@@ -0,0 +1,165 @@
+package gunp
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	logger "gunp/internal/log"
+	"hash"
+	"strings"
+	"unicode"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	fdiff "github.com/go-git/go-git/v6/plumbing/format/diff"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/go-git/go-git/v6/plumbing/storer"
+)
+
+// EquivalentUpstream works like `git cherry`: it returns the hashes of the
+// given commits whose diff already exists on the upstream branch, e.g. after
+// a rebase or a squash of a single commit done on the remote.
+func EquivalentUpstream(repo *git.Repository, commits []*object.Commit) map[plumbing.Hash]bool {
+	equivalent := map[plumbing.Hash]bool{}
+	if len(commits) == 0 {
+		return equivalent
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		logger.Get().Error("get HEAD err:", "err", err)
+		return equivalent
+	}
+	remoteRef, err := upstreamRef(repo, head)
+	if err != nil {
+		logger.Get().Error("get REMOTE", "err", err)
+		return equivalent
+	}
+	localCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return equivalent
+	}
+	remoteCommit, err := repo.CommitObject(remoteRef.Hash())
+	if err != nil {
+		return equivalent
+	}
+	bases, err := localCommit.MergeBase(remoteCommit)
+	if err != nil || len(bases) == 0 {
+		return equivalent
+	}
+	stopHash := bases[0].Hash
+
+	// patch-ids of the commits that are only on the upstream side
+	upstreamIDs := map[string]bool{}
+	cIter, err := repo.Log(&git.LogOptions{
+		From: remoteRef.Hash(),
+		To:   stopHash,
+	})
+	if err != nil {
+		logger.Get().Error("get LOGS", "err", err)
+		return equivalent
+	}
+	defer cIter.Close()
+	iterErr := cIter.ForEach(func(c *object.Commit) error {
+		if c.Hash == stopHash {
+			return storer.ErrStop
+		}
+		if id, ok := patchID(c); ok {
+			upstreamIDs[id] = true
+		}
+		return nil
+	})
+	if iterErr != nil {
+		logger.Get().Error("iter COMMITS", "err", iterErr)
+		return equivalent
+	}
+
+	for _, c := range commits {
+		if id, ok := patchID(c); ok && upstreamIDs[id] {
+			equivalent[c.Hash] = true
+		}
+	}
+	logger.Get().Debug("cherry", "upstream patches", len(upstreamIDs), "equivalent", len(equivalent))
+	return equivalent
+}
+
+// patchID computes a stable id of the changes introduced by a commit.
+// Like `git patch-id` it ignores whitespace and line numbers, so the same
+// change applied on top of a different base gets the same id.
+// Merge commits have no patch-id.
+func patchID(c *object.Commit) (string, bool) {
+	if c.NumParents() > 1 {
+		return "", false
+	}
+
+	patch, err := CommitPatch(c)
+	if err != nil {
+		logger.Get().Debug("patch-id", "hash", c.Hash.String(), "err", err)
+		return "", false
+	}
+
+	h := sha1.New()
+	for _, fp := range patch.FilePatches() {
+		from, to := fp.Files()
+		if from != nil {
+			writeStripped(h, "---"+from.Path())
+		}
+		if to != nil {
+			writeStripped(h, "+++"+to.Path())
+		}
+		if fp.IsBinary() {
+			if to != nil {
+				writeStripped(h, to.Hash().String())
+			}
+			continue
+		}
+		for _, chunk := range fp.Chunks() {
+			var prefix string
+			switch chunk.Type() {
+			case fdiff.Add:
+				prefix = "+"
+			case fdiff.Delete:
+				prefix = "-"
+			default:
+				continue
+			}
+			for _, line := range strings.Split(strings.TrimSuffix(chunk.Content(), "\n"), "\n") {
+				writeStripped(h, prefix+line)
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// writeStripped hashes a line without its whitespace, ended by a newline:
+// the same content split in other lines doesn't get the same id
+func writeStripped(h hash.Hash, line string) {
+	h.Write([]byte(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, line)))
+	h.Write([]byte("\n"))
+}
+
+// CommitPatch returns the changes introduced by a commit, against its first parent
+// or against an empty tree for a root commit.
+func CommitPatch(c *object.Commit) (*object.Patch, error) {
+	if c.NumParents() == 0 {
+		tree, err := c.Tree()
+		if err != nil {
+			return nil, err
+		}
+		changes, err := object.DiffTree(nil, tree)
+		if err != nil {
+			return nil, err
+		}
+		return changes.Patch()
+	}
+
+	parent, err := c.Parent(0)
+	if err != nil {
+		return nil, err
+	}
+	return parent.Patch(c)
+}
@@ -0,0 +1,65 @@
+package gunp
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"testing"
+
+	"github.com/go-git/go-git/v6/plumbing"
+)
+
+// rebasedUpstreamRepo has local-1 and local-2 unpushed, local-1 was rebased on top of up-1 upstream
+func rebasedUpstreamRepo(t *testing.T) (*testRepo, map[string]string) {
+	t.Helper()
+	tr := newTestRepo(t)
+	tr.commit("base-1")
+	branch, base := tr.branch(), tr.head()
+
+	tr.checkout("up", base)
+	tr.commit("up-1")
+	tr.commit("local-1")
+	tr.setRef("refs/remotes/origin/"+branch, tr.head())
+
+	tr.checkout(branch, plumbing.ZeroHash)
+	hashes := map[string]string{}
+	for _, message := range []string{"local-1", "local-2"} {
+		hashes[message] = tr.commit(message).String()
+	}
+	return tr, hashes
+}
+
+func TestGitStatsCherry(t *testing.T) {
+	tr, hashes := rebasedUpstreamRepo(t)
+
+	stats := GitStats(tr.dir, Options{Cherry: true})
+	if len(stats.UnpushedCommits) != 2 {
+		t.Fatalf("got %d unpushed commits, want 2", len(stats.UnpushedCommits))
+	}
+	for _, c := range stats.UnpushedCommits {
+		want := c.Hash.String() == hashes["local-1"]
+		if stats.IsEquivalent(c.Hash) != want {
+			t.Errorf("%s: got equivalent %v, want %v", c.Message, stats.IsEquivalent(c.Hash), want)
+		}
+	}
+
+	stats = GitStats(tr.dir, Options{HideEquivalent: true})
+	if len(stats.UnpushedCommits) != 1 || stats.UnpushedCommits[0].Hash.String() != hashes["local-2"] {
+		t.Errorf("got %v with --hide-equivalent, want local-2 only", stats.UnpushedCommits)
+	}
+}
+
+func TestWriteStrippedSeparatesLines(t *testing.T) {
+	sum := func(lines ...string) string {
+		h := sha1.New()
+		for _, line := range lines {
+			writeStripped(h, line)
+		}
+		return hex.EncodeToString(h.Sum(nil))
+	}
+	if sum("+ab") == sum("+a", "b") {
+		t.Error("the same content split in other lines got the same id")
+	}
+	if sum("+a b") != sum("+ab ") {
+		t.Error("the whitespace changed the id")
+	}
+}
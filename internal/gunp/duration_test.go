@@ -0,0 +1,59 @@
+package gunp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"2w":   14 * 24 * time.Hour,
+		"7d":   7 * 24 * time.Hour,
+		"1.5d": 36 * time.Hour,
+		"36h":  36 * time.Hour,
+		" 2w ": 14 * 24 * time.Hour,
+	} {
+		got, err := ParseAge(s)
+		if err != nil || got != want {
+			t.Errorf("ParseAge(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "w", "-2w", "2y", "-1h"} {
+		if _, err := ParseAge(s); err == nil {
+			t.Errorf("ParseAge(%q) want an error", s)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	day := time.Date(2024, 3, 15, 0, 0, 0, 0, time.Local)
+	for _, tc := range []struct {
+		s        string
+		endOfDay bool
+		want     time.Time
+	}{
+		{"2024-03-15", false, day},
+		{"2024-03-15", true, day.AddDate(0, 0, 1).Add(-time.Nanosecond)},
+		{"2024-03-15 14:30", true, day.Add(14*time.Hour + 30*time.Minute)},
+		{"2024-03-15T14:30:05", false, day.Add(14*time.Hour + 30*time.Minute + 5*time.Second)},
+		{"2024-03-15T14:30:00Z", false, time.Date(2024, 3, 15, 14, 30, 0, 0, time.UTC)},
+	} {
+		got, err := ParseDate(tc.s, tc.endOfDay)
+		if err != nil || !got.Equal(tc.want) {
+			t.Errorf("ParseDate(%q, %v) = %v, %v, want %v", tc.s, tc.endOfDay, got, err, tc.want)
+		}
+	}
+
+	// ages are relative to now, whatever endOfDay
+	for _, endOfDay := range []bool{false, true} {
+		got, err := ParseDate("2w", endOfDay)
+		want := time.Now().AddDate(0, 0, -14)
+		if err != nil || got.Sub(want).Abs() > time.Minute {
+			t.Errorf("ParseDate(2w, %v) = %v, %v, want about %v", endOfDay, got, err, want)
+		}
+	}
+
+	if _, err := ParseDate("15/03/2024", false); err == nil {
+		t.Error("want an error for an unknown layout")
+	}
+}
@@ -0,0 +1,129 @@
+package gunp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	logger "gunp/internal/log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+)
+
+// lastFetchRecord is touched after every successful fetch done by gunp, relative to the .git directory.
+// go-git doesn't write FETCH_HEAD nor the reflogs that LastFetch reads.
+const lastFetchRecord = "gunp/last-fetch"
+
+// FetchResult is the outcome of fetching the remotes of a repo
+type FetchResult struct {
+	Path string
+	Err  error
+}
+
+// FetchRepo fetches every remote of the repository within the given timeout.
+// Authentication relies on what go-git supports out of the box (e.g. the SSH agent and known_hosts).
+func FetchRepo(gitDir string, timeout time.Duration) error {
+	r, err := git.PlainOpen(gitDir)
+	if err != nil {
+		return err
+	}
+	remotes, err := r.Remotes()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var errs []error
+	for _, remote := range remotes {
+		name := remote.Config().Name
+		err := r.FetchContext(ctx, &git.FetchOptions{RemoteName: name})
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			errs = append(errs, fmt.Errorf("fetch %s: %w", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return touchLastFetch(gitDir)
+}
+
+func touchLastFetch(gitDir string) error {
+	path := filepath.Join(gitDir, ".git", lastFetchRecord)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(time.Now().Format(time.RFC3339)+"\n"), 0o644)
+}
+
+// fetchErrors keeps the fetch failures by repo path, so the scan can record them
+type fetchErrors struct {
+	mu   sync.Mutex
+	errs map[string]error
+}
+
+func (f *fetchErrors) set(path string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs[path] = err
+}
+
+func (f *fetchErrors) get(path string) error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.errs[path]
+}
+
+// fetchStage fetches the repos coming from gitPathsCh with opts.FetchJobs workers and
+// passes them on through the returned channel once fetched, failed or not.
+// The results are also streamed on fetchedCh if not nil, which is closed when done.
+func fetchStage(gitPathsCh <-chan string, fetchedCh chan<- FetchResult, opts Options) (<-chan string, *fetchErrors) {
+	fetchedPathsCh := make(chan string, opts.FetchJobs)
+	errs := &fetchErrors{errs: map[string]error{}}
+
+	numberOfWorkers := opts.FetchJobs
+	if numberOfWorkers < 1 {
+		numberOfWorkers = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < numberOfWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for gitPath := range gitPathsCh {
+				err := FetchRepo(gitPath, opts.FetchTimeout)
+				if err != nil {
+					logger.Get().Error("FETCH", "gitDir", gitPath, "err", err)
+					errs.set(gitPath, err)
+				}
+				if fetchedCh != nil {
+					fetchedCh <- FetchResult{Path: gitPath, Err: err}
+				}
+				fetchedPathsCh <- gitPath
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(fetchedPathsCh)
+		if fetchedCh != nil {
+			close(fetchedCh)
+		}
+	}()
+
+	return fetchedPathsCh, errs
+}
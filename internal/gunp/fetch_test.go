@@ -0,0 +1,84 @@
+package gunp
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/config"
+	"github.com/go-git/go-git/v6/plumbing"
+)
+
+func TestFetchStageFileRemote(t *testing.T) {
+	remoteDir := filepath.Join(t.TempDir(), "remote.git")
+	if _, err := git.PlainInit(remoteDir, true); err != nil {
+		t.Fatal(err)
+	}
+	remoteURL := "file://" + filepath.ToSlash(remoteDir)
+
+	// the local repo pushed base-1 and has local-1 unpushed
+	local := newTestRepo(t)
+	local.commit("base-1")
+	head, err := local.repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	branch := head.Name()
+	if _, err := local.repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remoteURL}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := local.repo.Push(&git.PushOptions{RemoteName: "origin"}); err != nil {
+		t.Fatal(err)
+	}
+	local.setRef("refs/remotes/origin/"+branch.Short(), head.Hash())
+	unpushed := local.commit("local-1")
+
+	if stats := GitStats(local.dir, Options{}); len(stats.UnpushedCommits) != 1 {
+		t.Fatalf("got %d unpushed commits before the fetch, want 1", len(stats.UnpushedCommits))
+	}
+
+	// a second clone pushes local-1 to the remote, the local repo doesn't know yet
+	second, err := git.PlainClone(filepath.Join(t.TempDir(), "second"), &git.CloneOptions{URL: remoteURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := second.CreateRemote(&config.RemoteConfig{Name: "local", URLs: []string{local.dir}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Fetch(&git.FetchOptions{RemoteName: "local"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Storer.SetReference(plumbing.NewHashReference(branch, unpushed)); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Push(&git.PushOptions{RemoteName: "origin", RefSpecs: []config.RefSpec{config.RefSpec(branch + ":" + branch)}}); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now().Add(-time.Second)
+	gitPathsCh := make(chan string, 1)
+	gitPathsCh <- local.dir
+	close(gitPathsCh)
+	fetchedCh := make(chan FetchResult, 1)
+	opts := Options{Fetch: true, FetchJobs: 2, FetchTimeout: time.Minute}
+	fetchedPathsCh, fetchErrs := fetchStage(gitPathsCh, fetchedCh, opts)
+	for range fetchedPathsCh {
+	}
+	for result := range fetchedCh {
+		if result.Path != local.dir || result.Err != nil {
+			t.Fatalf("got the fetch result %+v", result)
+		}
+	}
+	if err := fetchErrs.get(local.dir); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := GitStats(local.dir, opts)
+	if len(stats.UnpushedCommits) != 0 {
+		t.Errorf("got %d unpushed commits after the fetch, want 0", len(stats.UnpushedCommits))
+	}
+	if stats.LastFetch.Before(start) {
+		t.Errorf("the last fetch %s was not updated", stats.LastFetch)
+	}
+}
@@ -0,0 +1,81 @@
+package gunp
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LastFetch returns when the remotes of the repository were last fetched,
+// reading the FETCH_HEAD mtime, the reflogs of the remote-tracking refs
+// and the record of the fetches done by gunp itself.
+// A push updates the remote-tracking refs too, only their reflog entries written by a fetch are counted.
+// It returns the zero time if the repository was never fetched.
+func LastFetch(gitDir string) time.Time {
+	var last time.Time
+	dotGit := filepath.Join(gitDir, ".git")
+
+	for _, record := range []string{"FETCH_HEAD", lastFetchRecord} {
+		if info, err := os.Stat(filepath.Join(dotGit, record)); err == nil && info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+
+	_ = filepath.WalkDir(filepath.Join(dotGit, "logs", "refs", "remotes"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if when := lastFetchReflogTime(path); when.After(last) {
+			last = when
+		}
+		return nil
+	})
+
+	return last
+}
+
+// fetchReflogActions are the reflog messages of the updates of the remote-tracking refs by a fetch,
+// e.g. "fetch origin: fast-forward", "pull: fast-forward" or "clone: from <url>", unlike "update by push"
+var fetchReflogActions = []string{"fetch", "pull", "clone"}
+
+// lastFetchReflogTime reads the time of the last entry of a reflog written by a fetch, whose lines look like:
+// <old-hash> <new-hash> <name> <<email>> <unix-time> <tz>\t<message>
+func lastFetchReflogTime(path string) time.Time {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}
+	}
+	defer f.Close()
+
+	var lastLine string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		_, message, _ := strings.Cut(line, "\t")
+		for _, action := range fetchReflogActions {
+			if strings.HasPrefix(message, action) {
+				lastLine = line
+				break
+			}
+		}
+	}
+
+	header, _, _ := strings.Cut(lastLine, "\t")
+	_, identity, ok := strings.Cut(header, "> ")
+	if !ok {
+		return time.Time{}
+	}
+	fields := strings.Fields(identity)
+	if len(fields) == 0 {
+		return time.Time{}
+	}
+	unix, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(unix, 0)
+}
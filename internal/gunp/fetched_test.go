@@ -0,0 +1,37 @@
+package gunp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLastFetchIgnoresPush(t *testing.T) {
+	tr := newTestRepo(t)
+	logs := filepath.Join(tr.dir, ".git", "logs", "refs", "remotes", "origin")
+	if err := os.MkdirAll(logs, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	zero := "0000000000000000000000000000000000000000"
+	hash := "1111111111111111111111111111111111111111"
+	fetched, pushed := time.Unix(1700000000, 0), time.Unix(1700086400, 0)
+	reflog := zero + " " + hash + " Test <test@example.com> 1700000000 +0000\tfetch origin: storing head\n" +
+		hash + " " + hash + " Test <test@example.com> 1700086400 +0000\tupdate by push\n"
+	if err := os.WriteFile(filepath.Join(logs, "main"), []byte(reflog), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := LastFetch(tr.dir); !got.Equal(fetched) {
+		t.Errorf("got the last fetch %s, want %s and not the push at %s", got, fetched, pushed)
+	}
+}
+
+func TestGitStatsNoRemoteNotStale(t *testing.T) {
+	tr := newTestRepo(t)
+	tr.commit("base-1")
+	stats := GitStats(tr.dir, Options{StaleAfter: time.Hour})
+	if stats.Stale || !stats.NoRemote {
+		t.Errorf("got stale %v and no remote %v for a repo without remotes", stats.Stale, stats.NoRemote)
+	}
+}
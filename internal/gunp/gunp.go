@@ -1,12 +1,14 @@
 package gunp
 
 import (
+	"fmt"
 	logger "gunp/internal/log"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-git/go-git/v6"
 	"github.com/go-git/go-git/v6/plumbing"
@@ -17,6 +19,44 @@ import (
 type GunpRepo struct {
 	Path            string
 	UnpushedCommits []*object.Commit
+	// Branch is the branch checked out, empty when HEAD is detached
+	Branch string
+	// Equivalent holds the unpushed commits whose patch is already upstream
+	Equivalent map[plumbing.Hash]bool
+	// UnpushedTags holds the names of the local tags that are not on the remote
+	UnpushedTags []string
+	// LastFetch is when the remotes were last fetched, zero if never
+	LastFetch time.Time
+	// Stale is true when the remotes were not fetched within Options.StaleAfter
+	Stale bool
+	// NoRemote is true for a repo without remotes, which is never stale
+	NoRemote bool
+	// FetchErr is the error of the fetch done before the scan with Options.Fetch
+	FetchErr error
+	// AuthorErr is why the commits of the user can't be told with Options.Mine, none are counted then
+	AuthorErr error
+	// OldestUnpushed and NewestUnpushed are the author dates of the oldest and newest unpushed commits, zero if none
+	OldestUnpushed time.Time
+	NewestUnpushed time.Time
+}
+
+// UnpushedAges returns the author dates of the oldest and newest commits, zero if none
+func UnpushedAges(commits []*object.Commit) (time.Time, time.Time) {
+	var oldest, newest time.Time
+	for _, c := range commits {
+		if oldest.IsZero() || c.Author.When.Before(oldest) {
+			oldest = c.Author.When
+		}
+		if newest.IsZero() || c.Author.When.After(newest) {
+			newest = c.Author.When
+		}
+	}
+	return oldest, newest
+}
+
+// IsEquivalent reports whether the commit is already upstream with a different hash.
+func (r *GunpRepo) IsEquivalent(hash plumbing.Hash) bool {
+	return r.Equivalent[hash]
 }
 
 /*
@@ -27,15 +67,17 @@ GunpTUI returns the following:
   - scanningDoneCh: chan bool - a channel that is closed when the scanning is done
   - walkedPathsCounter: *gunp.Counter - a counter that tracks the walked paths count as they are discovered one by one
   - gitPathsCh: chan string - a channel that stream the git paths as they are discovered one by one
+  - fetchedCh: chan FetchResult - a channel that stream the fetch results one by one, closed when the fetching is done (right away without Options.Fetch)
   - gunpReposCh: chan *GunpRepo - a channel that stream the gunp repos as they are discovered one by one
   - err: error - an error if any
 */
-func GunpTUI() (string, chan bool, chan bool, *Counter, chan string, chan *GunpRepo, error) {
+func GunpTUI(opts Options) (string, chan bool, chan bool, *Counter, chan string, chan FetchResult, chan *GunpRepo, error) {
 	concurrencyGlobal := 10 // number of workers for the stats
 	discoveryDoneCh := make(chan bool)
 	scanningDoneCh := make(chan bool)
 	walkedPathsCounter := NewCounter()
 	gitPathsCh := make(chan string, 1)
+	fetchedCh := make(chan FetchResult, concurrencyGlobal)
 	gunpReposCh := make(chan *GunpRepo, concurrencyGlobal)
 
 	rootDir := rootCwd()
@@ -59,14 +101,44 @@ func GunpTUI() (string, chan bool, chan bool, *Counter, chan string, chan *GunpR
 		}
 		// discoveryDoneCh <- true
 	}()
+
+	var statsPathsCh <-chan string = gitPathsChForStats
+	var fetchErrs *fetchErrors
+	if opts.Fetch {
+		statsPathsCh, fetchErrs = fetchStage(gitPathsChForStats, fetchedCh, opts)
+	} else {
+		close(fetchedCh)
+	}
+
 	go func() {
 		// <-discoveryDoneCh
 		// defer close(gunpReposCh)
 		defer close(scanningDoneCh)
-		gunpStats(gitPathsChForStats, gunpReposCh, concurrencyGlobal)
+		gunpStats(statsPathsCh, gunpReposCh, concurrencyGlobal, opts, fetchErrs)
 		// scanningDoneCh <- true
 	}()
-	return rootDir, discoveryDoneCh, scanningDoneCh, walkedPathsCounter, gitPathsCh, gunpReposCh, nil
+	return rootDir, discoveryDoneCh, scanningDoneCh, walkedPathsCounter, gitPathsCh, fetchedCh, gunpReposCh, nil
+}
+
+// Scan recursively explore the current folder and returns the stats of every git repository,
+// without streaming the progress like GunpTUI does.
+func Scan(opts Options) (string, []*GunpRepo) {
+	concurrencyGlobal := 10 // number of workers for the stats
+	rootDir := rootCwd()
+
+	gitPathsCh := make(chan string, concurrencyGlobal)
+	go func() {
+		defer close(gitPathsCh)
+		gitPaths(rootDir, gitPathsCh, nil)
+	}()
+
+	var statsPathsCh <-chan string = gitPathsCh
+	var fetchErrs *fetchErrors
+	if opts.Fetch {
+		statsPathsCh, fetchErrs = fetchStage(gitPathsCh, nil, opts)
+	}
+
+	return rootDir, gunpStats(statsPathsCh, nil, concurrencyGlobal, opts, fetchErrs)
 }
 
 // main algorithm that recursively explore the current folder and get git status
@@ -79,7 +151,7 @@ func gunp() {
 	globalCount := 0
 
 	for _, currGitPath := range paths {
-		stats := GitStats(currGitPath)
+		stats := GitStats(currGitPath, Options{})
 		// slog.Debug("Git Status by repo", "stats", stats)
 		if len(stats.UnpushedCommits) > 0 {
 			logger.Get().Print("-", stats.Path, len(stats.UnpushedCommits))
@@ -144,18 +216,25 @@ func fullpath(root string, pathName string) string {
 	return filepath.Join(root, pathName)
 }
 
-func RefreshRepos(gitPathsCh chan string, gunpReposCh chan *GunpRepo) []*GunpRepo {
+func RefreshRepos(gitPathsCh chan string, gunpReposCh chan *GunpRepo, opts Options) []*GunpRepo {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	var gunpRepos []*GunpRepo
 	numberOfWorkers := 10
 
+	var statsPathsCh <-chan string = gitPathsCh
+	var fetchErrs *fetchErrors
+	if opts.Fetch {
+		statsPathsCh, fetchErrs = fetchStage(gitPathsCh, nil, opts)
+	}
+
 	for i := 0; i < numberOfWorkers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for gitPath := range gitPathsCh {
-				stats := GitStats(gitPath)
+			for gitPath := range statsPathsCh {
+				stats := GitStats(gitPath, opts)
+				stats.FetchErr = fetchErrs.get(gitPath)
 				if gunpReposCh != nil {
 					gunpReposCh <- stats
 				}
@@ -174,7 +253,7 @@ func RefreshRepos(gitPathsCh chan string, gunpReposCh chan *GunpRepo) []*GunpRep
 	return gunpRepos
 }
 
-func gunpStats(gitPathsCh chan string, gunpReposCh chan *GunpRepo, numberOfWorkers int) []*GunpRepo {
+func gunpStats(gitPathsCh <-chan string, gunpReposCh chan *GunpRepo, numberOfWorkers int, opts Options, fetchErrs *fetchErrors) []*GunpRepo {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	var gunpRepos []*GunpRepo
@@ -184,7 +263,8 @@ func gunpStats(gitPathsCh chan string, gunpReposCh chan *GunpRepo, numberOfWorke
 		go func() {
 			defer wg.Done()
 			for gitPath := range gitPathsCh {
-				stats := GitStats(gitPath)
+				stats := GitStats(gitPath, opts)
+				stats.FetchErr = fetchErrs.get(gitPath)
 				if gunpReposCh != nil {
 					gunpReposCh <- stats
 				}
@@ -203,7 +283,10 @@ func gunpStats(gitPathsCh chan string, gunpReposCh chan *GunpRepo, numberOfWorke
 	return gunpRepos
 }
 
-func GitStats(gitDir string) *GunpRepo {
+func GitStats(gitDir string, opts Options) *GunpRepo {
+	lastFetch := LastFetch(gitDir)
+	stale := opts.StaleAfter > 0 && time.Since(lastFetch) > opts.StaleAfter
+
 	r, err := git.PlainOpen(gitDir)
 	if err != nil {
 		logger.Get().Error("Git open repository", "gitDir", gitDir, "err", err)
@@ -211,19 +294,71 @@ func GitStats(gitDir string) *GunpRepo {
 		return &GunpRepo{
 			Path:            gitDir,
 			UnpushedCommits: []*object.Commit{},
+			LastFetch:       lastFetch,
+			Stale:           stale,
 		}
 	}
 
-	unpushedCount := GetUnpushedCommits(r)
+	// a repo without remotes is never fetched, its results are not stale
+	remotes, err := r.Remotes()
+	noRemote := err == nil && len(remotes) == 0
+	if noRemote {
+		stale = false
+	}
+
+	author, authorErr := opts.Author, error(nil)
+	if author == nil && opts.Mine {
+		if author, authorErr = MinePattern(r); authorErr != nil {
+			logger.Get().Error("--mine", "gitDir", gitDir, "err", authorErr)
+		}
+	}
+	unpushedCount := []*object.Commit{}
+	if authorErr == nil {
+		unpushedCount = FilterByAuthor(GetUnpushedCommits(r, opts), author)
+	}
 	logger.Get().Info("UNPUSHED", "gitDir", gitDir, "unpushed commits", len(unpushedCount))
 
-	return &GunpRepo{
+	gunpRepo := &GunpRepo{
 		Path:            gitDir,
 		UnpushedCommits: unpushedCount,
+		LastFetch:       lastFetch,
+		Stale:           stale,
+		NoRemote:        noRemote,
+		AuthorErr:       authorErr,
+	}
+	if head, err := r.Head(); err == nil && head.Name().IsBranch() {
+		gunpRepo.Branch = head.Name().Short()
+	}
+
+	if opts.Cherry || opts.HideEquivalent {
+		gunpRepo.Equivalent = EquivalentUpstream(r, unpushedCount)
+		if opts.HideEquivalent && len(gunpRepo.Equivalent) > 0 {
+			var commits []*object.Commit
+			for _, c := range unpushedCount {
+				if !gunpRepo.IsEquivalent(c.Hash) {
+					commits = append(commits, c)
+				}
+			}
+			gunpRepo.UnpushedCommits = commits
+		}
+	}
+
+	if opts.Tags || opts.RemoteTags {
+		if opts.RemoteTags {
+			if err := RefreshRemoteTags(r, gitDir); err != nil {
+				logger.Get().Error("refresh remote tags", "gitDir", gitDir, "err", err)
+			}
+		}
+		gunpRepo.UnpushedTags = GetUnpushedTags(r, gitDir)
 	}
+
+	gunpRepo.OldestUnpushed, gunpRepo.NewestUnpushed = UnpushedAges(gunpRepo.UnpushedCommits)
+	return gunpRepo
 }
 
-func GetUnpushedCommits(repo *git.Repository) []*object.Commit {
+// GetUnpushedCommits walks the commits of HEAD down to the merge base with its upstream.
+// The commits committed outside of opts.Since and opts.Until are left out.
+func GetUnpushedCommits(repo *git.Repository, opts Options) []*object.Commit {
 	var commits []*object.Commit
 
 	// Get the local HEAD reference
@@ -233,28 +368,11 @@ func GetUnpushedCommits(repo *git.Repository) []*object.Commit {
 		return commits
 	}
 
-	config, err := repo.Config()
-	if err != nil {
-		logger.Get().Error("get CONFIG", "err", err)
-		return commits
-	}
-
-	var remoteName string
 	var stopHash plumbing.Hash // Defaults to ZeroHash (walk all history)
 
-	branchName := head.Name().Short()
-	branchConfig := config.Branches[branchName]
-	if branchConfig != nil && branchConfig.Remote != "" && branchConfig.Merge != "" {
-		// there is a REMOTE branch to track
-		remoteName = "refs/remotes/" + branchConfig.Remote + "/" + branchConfig.Merge.Short()
-		// remoteName := branchConfig.Remote + "/" + branchConfig.Merge.Short()
-	} else {
-		remoteName = "refs/remotes/origin/" + branchName
-	}
-
-	remoteRef, err := repo.Reference(plumbing.ReferenceName(remoteName), true)
+	remoteRef, err := upstreamRef(repo, head)
 	if err != nil {
-		logger.Get().Error("get REMOTE", "remoteName", remoteName, "err", err)
+		logger.Get().Error("get REMOTE", "err", err)
 		return commits
 		// goto iterCommits
 	}
@@ -265,7 +383,7 @@ func GetUnpushedCommits(repo *git.Repository) []*object.Commit {
 		return commits
 	}
 	stopHash = bases[0].Hash
-	logger.Get().Debug("remoteRef", "remoteName", remoteName, "hash", remoteRef.Hash().String())
+	logger.Get().Debug("remoteRef", "remoteName", remoteRef.Name().String(), "hash", remoteRef.Hash().String())
 
 	// iterCommits:
 
@@ -282,10 +400,20 @@ func GetUnpushedCommits(repo *git.Repository) []*object.Commit {
 		logger.Get().Error("get LOGS", "err", err)
 		return commits
 	}
+	// the date range is applied on top of the walk bounded by To, not through the Since/Until of the LogOptions:
+	// go-git checks them before To, so a merge base out of the range would not stop the walk
+	// and the pushed history would be walked (and counted) too
+	if opts.Since != nil || opts.Until != nil {
+		cIter = object.NewCommitLimitIterFromIter(cIter, object.LogLimitOptions{Since: opts.Since, Until: opts.Until})
+	}
 
 	defer cIter.Close()
 
 	iterErr := cIter.ForEach(func(c *object.Commit) error {
+		// the date range iterator ends with a nil commit when the merge base is reached
+		if c == nil {
+			return storer.ErrStop
+		}
 		logger.Get().Debug("commit", "hash", c.Hash.String())
 		if c.Hash == stopHash {
 			return storer.ErrStop
@@ -300,3 +428,28 @@ func GetUnpushedCommits(repo *git.Repository) []*object.Commit {
 
 	return commits
 }
+
+// upstreamRef resolves the remote-tracking reference of the branch checked out at head:
+// the configured upstream if any, otherwise origin/<branch>.
+func upstreamRef(repo *git.Repository, head *plumbing.Reference) (*plumbing.Reference, error) {
+	config, err := repo.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	var remoteName string
+	branchName := head.Name().Short()
+	branchConfig := config.Branches[branchName]
+	if branchConfig != nil && branchConfig.Remote != "" && branchConfig.Merge != "" {
+		// there is a REMOTE branch to track
+		remoteName = "refs/remotes/" + branchConfig.Remote + "/" + branchConfig.Merge.Short()
+	} else {
+		remoteName = "refs/remotes/origin/" + branchName
+	}
+
+	remoteRef, err := repo.Reference(plumbing.ReferenceName(remoteName), true)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", remoteName, err)
+	}
+	return remoteRef, nil
+}
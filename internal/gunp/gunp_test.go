@@ -0,0 +1,84 @@
+package gunp
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+// datedRepo builds a branch with dated commits: old-root (2024-03-14) and pushed (2024-01-01, the merge base)
+// are pushed, local-10, local-15 (at 10:00) and local-20 on the 10th, 15th and 20th of March 2024 are unpushed
+func datedRepo(t *testing.T) (*testRepo, map[string]plumbing.Hash) {
+	t.Helper()
+	tr := newTestRepo(t)
+	march := func(day, hour int) time.Time { return time.Date(2024, 3, day, hour, 0, 0, 0, time.Local) }
+	hashes := map[string]plumbing.Hash{}
+	// the pushed root is in the ranges tested, only the merge base must stop the walk
+	hashes["old-root"] = tr.commitAt("old-root", march(14, 12))
+	hashes["pushed"] = tr.commitAt("pushed", time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local))
+	tr.setRef("refs/remotes/origin/"+tr.branch(), hashes["pushed"])
+	hashes["local-10"] = tr.commitAt("local-10", march(10, 12))
+	hashes["local-15"] = tr.commitAt("local-15", march(15, 10))
+	hashes["local-20"] = tr.commitAt("local-20", march(20, 12))
+	return tr, hashes
+}
+
+func commitHashes(commits []*object.Commit) []plumbing.Hash {
+	var hashes []plumbing.Hash
+	for _, c := range commits {
+		hashes = append(hashes, c.Hash)
+	}
+	return hashes
+}
+
+func TestGetUnpushedCommitsDateRange(t *testing.T) {
+	tr, h := datedRepo(t)
+	date := func(s string, endOfDay bool) *time.Time {
+		d, err := ParseDate(s, endOfDay)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &d
+	}
+
+	for _, tc := range []struct {
+		name string
+		opts Options
+		want []plumbing.Hash
+	}{
+		{"no range", Options{}, []plumbing.Hash{h["local-20"], h["local-15"], h["local-10"]}},
+		// the merge base is before --since, the pushed old-root after it is not counted
+		{"merge base before since", Options{Since: date("2024-03-12", false)}, []plumbing.Hash{h["local-20"], h["local-15"]}},
+		// a day-only --until includes the commits of that day
+		{"day-only until", Options{Until: date("2024-03-15", true)}, []plumbing.Hash{h["local-15"], h["local-10"]}},
+		{"since and until", Options{Since: date("2024-03-15", false), Until: date("2024-03-15", true)}, []plumbing.Hash{h["local-15"]}},
+		{"empty range", Options{Since: date("2024-03-16", false), Until: date("2024-03-19", true)}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := commitHashes(GetUnpushedCommits(tr.repo, tc.opts))
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGetUnpushedCommitsRelativeSince(t *testing.T) {
+	tr := newTestRepo(t)
+	pushed := tr.commitAt("pushed", time.Now().AddDate(0, 0, -30))
+	tr.setRef("refs/remotes/origin/"+tr.branch(), pushed)
+	tr.commitAt("three-weeks", time.Now().AddDate(0, 0, -21))
+	recent := tr.commitAt("recent", time.Now().AddDate(0, 0, -1))
+
+	since, err := ParseDate("2w", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := commitHashes(GetUnpushedCommits(tr.repo, Options{Since: &since}))
+	if !slices.Equal(got, []plumbing.Hash{recent}) {
+		t.Errorf("got %v, want only the recent commit %v", got, recent)
+	}
+}
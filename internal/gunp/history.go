@@ -0,0 +1,202 @@
+package gunp
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// snapshotTimeFormat names the snapshot files, sortable and unique across runs
+const snapshotTimeFormat = "2006-01-02T15-04-05.000000000Z"
+
+// the retention of the history, the older snapshots are removed when a snapshot is saved
+const (
+	historyMaxAge       = 365 * 24 * time.Hour
+	historyMaxSnapshots = 2000
+)
+
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// Snapshot is the compact record of a scan kept in the history, to follow the unpushed work over time.
+type Snapshot struct {
+	Time    time.Time `json:"time"`
+	RootDir string    `json:"rootDir"`
+	// Unpushed is the total of the unpushed commits of the repos
+	Unpushed int `json:"unpushed"`
+	// Repos are the repos with unpushed commits
+	Repos []SnapshotRepo `json:"repos"`
+}
+
+type SnapshotRepo struct {
+	Path string `json:"path"`
+	// Branch is the branch checked out, empty when HEAD is detached
+	Branch string `json:"branch,omitempty"`
+	// Ahead is the number of unpushed commits
+	Ahead  int        `json:"ahead"`
+	Oldest *time.Time `json:"oldest,omitempty"`
+}
+
+// NewSnapshot records the unpushed commits of the scanned repos.
+func NewSnapshot(rootDir string, gunpRepos []*GunpRepo) Snapshot {
+	snapshot := Snapshot{
+		Time:    time.Now(),
+		RootDir: rootDir,
+		Repos:   []SnapshotRepo{},
+	}
+	for _, repo := range gunpRepos {
+		if len(repo.UnpushedCommits) == 0 {
+			continue
+		}
+		snapshotRepo := SnapshotRepo{Path: repo.Path, Branch: repo.Branch, Ahead: len(repo.UnpushedCommits)}
+		if !repo.OldestUnpushed.IsZero() {
+			oldest := repo.OldestUnpushed
+			snapshotRepo.Oldest = &oldest
+		}
+		snapshot.Unpushed += snapshotRepo.Ahead
+		snapshot.Repos = append(snapshot.Repos, snapshotRepo)
+	}
+	slices.SortFunc(snapshot.Repos, func(a, b SnapshotRepo) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return snapshot
+}
+
+// HistoryDir is where the snapshots are kept: $XDG_STATE_HOME/gunp/history (~/.local/state/gunp/history)
+func HistoryDir() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "gunp", "history"), nil
+}
+
+// SaveSnapshot adds the snapshot to the history, one file per snapshot,
+// and removes the snapshots beyond the retention: older than a year, or past the last 2000 of all the folders.
+func SaveSnapshot(snapshot Snapshot) error {
+	dir, err := HistoryDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(dir, snapshot.Time.UTC().Format(snapshotTimeFormat)+".json")
+	if err := os.WriteFile(file, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return pruneHistory(dir, snapshot.Time)
+}
+
+// pruneHistory removes the snapshots beyond the retention, their names sort them oldest first
+func pruneHistory(dir string, now time.Time) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
+			files = append(files, entry.Name())
+		}
+	}
+	slices.Sort(files)
+
+	var errs []error
+	for i, file := range files {
+		taken, err := time.Parse(snapshotTimeFormat, strings.TrimSuffix(file, ".json"))
+		if err != nil {
+			continue
+		}
+		if len(files)-i <= historyMaxSnapshots && now.Sub(taken) <= historyMaxAge {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, file)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// History returns the snapshots of the scans of rootDir, oldest first. Unreadable snapshots are skipped.
+func History(rootDir string) ([]Snapshot, error) {
+	dir, err := HistoryDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var history []Snapshot
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		var snapshot Snapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil || snapshot.RootDir != rootDir {
+			continue
+		}
+		snapshot.Time = snapshot.Time.Local()
+		history = append(history, snapshot)
+	}
+	slices.SortFunc(history, func(a, b Snapshot) int {
+		return a.Time.Compare(b.Time)
+	})
+	return history, nil
+}
+
+// Weekly keeps the last snapshot of every ISO week of the history, oldest first.
+func Weekly(history []Snapshot) []Snapshot {
+	var weekly []Snapshot
+	for _, snapshot := range history {
+		if n := len(weekly); n > 0 && sameWeek(weekly[n-1].Time, snapshot.Time) {
+			weekly[n-1] = snapshot
+			continue
+		}
+		weekly = append(weekly, snapshot)
+	}
+	return weekly
+}
+
+func sameWeek(a, b time.Time) bool {
+	aYear, aWeek := a.ISOWeek()
+	bYear, bWeek := b.ISOWeek()
+	return aYear == bYear && aWeek == bWeek
+}
+
+// Sparkline draws the values with block characters, from the lowest to the highest value.
+func Sparkline(values []int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	low, high := slices.Min(values), slices.Max(values)
+	var b strings.Builder
+	for _, v := range values {
+		bar := 0
+		if high > low {
+			bar = (v - low) * (len(sparkBars) - 1) / (high - low)
+		}
+		b.WriteRune(sparkBars[bar])
+	}
+	return b.String()
+}
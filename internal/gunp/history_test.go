@@ -0,0 +1,48 @@
+package gunp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveSnapshotPrunesHistory(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir, err := HistoryDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	expired := now.Add(-historyMaxAge - time.Hour)
+	if err := os.WriteFile(filepath.Join(dir, expired.UTC().Format(snapshotTimeFormat)+".json"), []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for i := range historyMaxSnapshots {
+		name := now.Add(time.Duration(i-historyMaxSnapshots) * time.Minute).UTC().Format(snapshotTimeFormat)
+		if err := os.WriteFile(filepath.Join(dir, name+".json"), []byte("{}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := SaveSnapshot(Snapshot{Time: now, RootDir: "/work"}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != historyMaxSnapshots {
+		t.Fatalf("got %d snapshots, want %d", len(entries), historyMaxSnapshots)
+	}
+	history, err := History("/work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || !history[0].Time.Equal(now) {
+		t.Errorf("the saved snapshot is not in the history: %+v", history)
+	}
+}
@@ -0,0 +1,39 @@
+package gunp
+
+import (
+	"regexp"
+	"time"
+)
+
+// Options tweaks how repositories are scanned.
+type Options struct {
+	// Cherry marks unpushed commits whose patch already exists upstream (like `git cherry`)
+	Cherry bool
+	// HideEquivalent drops the commits marked by Cherry from the unpushed list
+	HideEquivalent bool
+	// Tags reports the local tags that are not on the remote
+	Tags bool
+	// RemoteTags lists the tags of the remotes to refresh the record of the last-known remote tags
+	RemoteTags bool
+	// StaleAfter marks the results of a repo as unreliable when its remotes were not fetched for longer (0 to disable)
+	StaleAfter time.Duration
+	// Fetch fetches the remotes of every repo before computing the unpushed commits
+	Fetch bool
+	// FetchJobs is the number of repos fetched concurrently
+	FetchJobs int
+	// FetchTimeout is the timeout of the fetch of a single repo (0 for no timeout)
+	FetchTimeout time.Duration
+	// Author keeps only the unpushed commits whose author ("Name <email>") matches, nil for all
+	Author *regexp.Regexp
+	// Mine keeps only the unpushed commits of the user.email of each repo, when Author is nil
+	Mine bool
+	// Since and Until keep only the unpushed commits committed in the range, nil for no limit
+	Since *time.Time
+	Until *time.Time
+}
+
+// Filtered reports whether only a part of the unpushed commits is counted, by author, by date or without the equivalent ones.
+// The scans filtered are not kept in the history, see SaveSnapshot.
+func (o Options) Filtered() bool {
+	return o.Author != nil || o.Mine || o.Since != nil || o.Until != nil || o.HideEquivalent
+}
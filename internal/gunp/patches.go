@@ -0,0 +1,209 @@
+package gunp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	logger "gunp/internal/log"
+	"mime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+// patchNameMax is the length limit of the subject in the name of a patch file, like git format-patch
+const patchNameMax = 52
+
+var patchNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._]+`)
+
+// PatchSeries is the patches of the unpushed commits of a branch, written by ExportPatches.
+type PatchSeries struct {
+	Path   string
+	Branch string
+	// Dir holds the patches, one file per commit numbered from the oldest
+	Dir     string
+	Patches int
+}
+
+// ExportPatches writes into dest the unpushed commits of the branches of every repo under the current folder
+// as patches in the mbox format of `git format-patch`, one folder per repo and branch, ready for `git am`.
+// Merge commits and commits without changes are skipped, like format-patch does.
+// A repo that fails is left out and its error is returned along the others.
+func ExportPatches(dest string) ([]PatchSeries, error) {
+	rootDir := rootCwd()
+	gitDirs, err := gitPathsOutside(rootDir, dest)
+	if err != nil {
+		return nil, err
+	}
+
+	var series []PatchSeries
+	var errs []error
+	for _, gitDir := range gitDirs {
+		repoSeries, err := exportRepoPatches(gitDir, filepath.Join(dest, backupName(rootDir, gitDir)))
+		if err != nil {
+			logger.Get().Error("export patches", "gitDir", gitDir, "err", err)
+			errs = append(errs, fmt.Errorf("%s: %w", gitDir, err))
+		}
+		series = append(series, repoSeries...)
+	}
+	return series, errors.Join(errs...)
+}
+
+func exportRepoPatches(gitDir, dest string) ([]PatchSeries, error) {
+	r, err := git.PlainOpen(gitDir)
+	if err != nil {
+		return nil, err
+	}
+	branches, err := unpushedBranches(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var series []PatchSeries
+	for _, branch := range branches {
+		tip, err := r.CommitObject(plumbing.NewHash(branch.Hash))
+		if err != nil {
+			return series, err
+		}
+		commits, err := branchCommits(r, tip, branch.Prerequisites)
+		if err != nil {
+			return series, err
+		}
+		commits = slices.DeleteFunc(commits, func(c *object.Commit) bool { return c.NumParents() > 1 })
+		slices.Reverse(commits)
+
+		name := plumbing.ReferenceName(branch.Name).Short()
+		branchSeries, err := writePatches(filepath.Join(dest, filepath.FromSlash(name)), commits)
+		if err != nil {
+			return series, fmt.Errorf("%s: %w", name, err)
+		}
+		if branchSeries.Patches == 0 {
+			continue
+		}
+		branchSeries.Path, branchSeries.Branch = gitDir, name
+		series = append(series, branchSeries)
+	}
+	return series, nil
+}
+
+// writePatches writes the patches of the commits, oldest first, replacing the patches already in dir
+func writePatches(dir string, commits []*object.Commit) (PatchSeries, error) {
+	series := PatchSeries{Dir: dir}
+	type commitPatch struct {
+		commit *object.Commit
+		patch  *object.Patch
+	}
+	var patches []commitPatch
+	for _, c := range commits {
+		patch, err := CommitPatch(c)
+		if err != nil {
+			return series, fmt.Errorf("%s: %w", c.Hash, err)
+		}
+		if len(patch.FilePatches()) > 0 {
+			patches = append(patches, commitPatch{c, patch})
+		}
+	}
+	if len(patches) == 0 {
+		return series, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return series, err
+	}
+	old, err := filepath.Glob(filepath.Join(dir, "*.patch"))
+	if err != nil {
+		return series, err
+	}
+	for _, file := range old {
+		if err := os.Remove(file); err != nil {
+			return series, err
+		}
+	}
+
+	for i, p := range patches {
+		subject, _, _ := strings.Cut(p.commit.Message, "\n")
+		name := strings.Trim(patchNameUnsafe.ReplaceAllString(subject, "-"), "-.")
+		if len(name) > patchNameMax {
+			name = strings.TrimRight(name[:patchNameMax], "-.")
+		}
+		file := filepath.Join(dir, fmt.Sprintf("%04d-%s.patch", i+1, name))
+
+		var mbox bytes.Buffer
+		if err := formatPatch(&mbox, p.commit, p.patch, i+1, len(patches)); err != nil {
+			return series, fmt.Errorf("%s: %w", p.commit.Hash, err)
+		}
+		if err := os.WriteFile(file, mbox.Bytes(), 0o644); err != nil {
+			return series, err
+		}
+		series.Patches++
+	}
+	return series, nil
+}
+
+// formatPatch writes the patch of a commit as a mail, the n-th of a series of total patches
+func formatPatch(w *bytes.Buffer, c *object.Commit, patch *object.Patch, n, total int) error {
+	subject, body, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+	prefix := "[PATCH]"
+	if total > 1 {
+		prefix = fmt.Sprintf("[PATCH %d/%d]", n, total)
+	}
+
+	fmt.Fprintf(w, "From %s Mon Sep 17 00:00:00 2001\n", c.Hash)
+	fmt.Fprintf(w, "From: %s <%s>\n", mime.QEncoding.Encode("UTF-8", c.Author.Name), c.Author.Email)
+	fmt.Fprintf(w, "Date: %s\n", c.Author.When.Format("Mon, 2 Jan 2006 15:04:05 -0700"))
+	fmt.Fprintf(w, "Subject: %s %s\n", prefix, mime.QEncoding.Encode("UTF-8", subject))
+	if !isASCII(c.Author.Name + c.Message) {
+		w.WriteString("MIME-Version: 1.0\n")
+		w.WriteString("Content-Type: text/plain; charset=UTF-8\n")
+		w.WriteString("Content-Transfer-Encoding: 8bit\n")
+	}
+	w.WriteString("\n")
+	if body = strings.TrimSpace(body); body != "" {
+		w.WriteString(body + "\n")
+	}
+
+	stats := patch.Stats()
+	insertions, deletions := 0, 0
+	for _, stat := range stats {
+		insertions += stat.Addition
+		deletions += stat.Deletion
+	}
+	w.WriteString("---\n")
+	w.WriteString(stats.String())
+	w.WriteString(" " + countOf(len(stats), "file", "files") + " changed")
+	if insertions > 0 || deletions == 0 {
+		w.WriteString(", " + countOf(insertions, "insertion(+)", "insertions(+)"))
+	}
+	if deletions > 0 || insertions == 0 {
+		w.WriteString(", " + countOf(deletions, "deletion(-)", "deletions(-)"))
+	}
+	w.WriteString("\n\n")
+	if err := patch.Encode(w); err != nil {
+		return err
+	}
+	w.WriteString("-- \ngunp\n\n")
+	return nil
+}
+
+func countOf(n int, singular, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,31 @@
+package gunp
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestExportRepoPatchesMergedUpstream(t *testing.T) {
+	tr := mergedUpstreamRepo(t)
+	dest := t.TempDir()
+	series, err := exportRepoPatches(tr.dir, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 1 {
+		t.Fatalf("got %d series, want feature only: %+v", len(series), series)
+	}
+	// feature-1 and feature-2, the merge is skipped and base-* are pushed
+	files, err := filepath.Glob(filepath.Join(dest, "feature", "*.patch"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if series[0].Patches != 2 || len(files) != 2 {
+		t.Fatalf("got %d patches and files %v, want 2", series[0].Patches, files)
+	}
+	for i, want := range []string{"0001-feature-1.patch", "0002-feature-2.patch"} {
+		if got := filepath.Base(files[i]); got != want {
+			t.Errorf("patch %d is %s, want %s", i+1, got, want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package gunp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/config"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v6/plumbing/transport"
+)
+
+// pushTimeout is the timeout of the push of a single repo
+const pushTimeout = 2 * time.Minute
+
+// PushPlan is the exact push of the current branch to its upstream, to be confirmed before running it
+type PushPlan struct {
+	Path    string
+	Remote  string
+	RefSpec config.RefSpec
+}
+
+func (p *PushPlan) String() string {
+	return fmt.Sprintf("git push %s %s", p.Remote, p.RefSpec)
+}
+
+type PushStatus int
+
+const (
+	PushOK PushStatus = iota
+	PushUpToDate
+	PushRejected
+	PushAuthFailed
+	PushFailed
+)
+
+func (s PushStatus) String() string {
+	switch s {
+	case PushOK:
+		return "ok"
+	case PushUpToDate:
+		return "up-to-date"
+	case PushRejected:
+		return "rejected (non-fast-forward)"
+	case PushAuthFailed:
+		return "auth failure"
+	}
+	return "failed"
+}
+
+type PushResult struct {
+	Plan   *PushPlan
+	Status PushStatus
+	Err    error
+}
+
+// PlanPush resolves where the current branch would be pushed:
+// the configured upstream if any, otherwise the same branch on origin.
+func PlanPush(gitDir string) (*PushPlan, error) {
+	r, err := git.PlainOpen(gitDir)
+	if err != nil {
+		return nil, err
+	}
+	head, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+	if !head.Name().IsBranch() {
+		return nil, errors.New("HEAD is detached")
+	}
+	cfg, err := r.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	remoteName := "origin"
+	dst := head.Name()
+	if branchConfig := cfg.Branches[head.Name().Short()]; branchConfig != nil && branchConfig.Remote != "" && branchConfig.Merge != "" {
+		remoteName = branchConfig.Remote
+		dst = branchConfig.Merge
+	}
+	if _, ok := cfg.Remotes[remoteName]; !ok {
+		return nil, fmt.Errorf("remote %q not found", remoteName)
+	}
+
+	return &PushPlan{
+		Path:    gitDir,
+		Remote:  remoteName,
+		RefSpec: config.RefSpec(fmt.Sprintf("%s:%s", head.Name(), dst)),
+	}, nil
+}
+
+// Push runs the push described by the plan, without forcing.
+// A push that is not a fast-forward of the remote branch is rejected before sending anything.
+func Push(plan *PushPlan) PushResult {
+	result := PushResult{Plan: plan}
+
+	r, err := git.PlainOpen(plan.Path)
+	if err != nil {
+		result.Status, result.Err = PushFailed, err
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+	defer cancel()
+
+	err = checkFastForward(ctx, r, plan)
+	if err == nil {
+		err = r.PushContext(ctx, &git.PushOptions{
+			RemoteName: plan.Remote,
+			RefSpecs:   []config.RefSpec{plan.RefSpec},
+		})
+	}
+	var statusErr packp.CommandStatusErr
+	switch {
+	case err == nil:
+		result.Status = PushOK
+	case errors.Is(err, git.NoErrAlreadyUpToDate):
+		result.Status = PushUpToDate
+	case errors.Is(err, transport.ErrAuthenticationRequired), errors.Is(err, transport.ErrAuthorizationFailed):
+		result.Status, result.Err = PushAuthFailed, err
+	case errors.Is(err, git.ErrNonFastForwardUpdate), errors.Is(err, git.ErrForceNeeded), errors.As(err, &statusErr):
+		// statusErr is the ref rejected by the remote, e.g. by a hook or a protected branch
+		result.Status, result.Err = PushRejected, err
+	default:
+		result.Status, result.Err = PushFailed, err
+	}
+	return result
+}
+
+// checkFastForward returns git.ErrNonFastForwardUpdate when the remote branch of the plan
+// has commits that the local branch doesn't have. go-git makes the same check while pushing
+// but its error is not typed.
+func checkFastForward(ctx context.Context, r *git.Repository, plan *PushPlan) error {
+	remote, err := r.Remote(plan.Remote)
+	if err != nil {
+		return err
+	}
+	refs, err := remote.ListContext(ctx, &git.ListOptions{})
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	dst := plan.RefSpec.Dst("")
+	var remoteHash plumbing.Hash
+	for _, ref := range refs {
+		if ref.Name() == dst {
+			remoteHash = ref.Hash()
+		}
+	}
+	if remoteHash.IsZero() {
+		return nil
+	}
+
+	local, err := r.Reference(plumbing.ReferenceName(plan.RefSpec.Src()), true)
+	if err != nil {
+		return err
+	}
+	if local.Hash() == remoteHash {
+		return git.NoErrAlreadyUpToDate
+	}
+	localCommit, err := r.CommitObject(local.Hash())
+	if err != nil {
+		return err
+	}
+	// the remote commit is unknown locally when someone else pushed it
+	remoteCommit, err := r.CommitObject(remoteHash)
+	if errors.Is(err, plumbing.ErrObjectNotFound) || (err == nil && !isAncestor(remoteCommit, localCommit)) {
+		return fmt.Errorf("%s: %w", dst, git.ErrNonFastForwardUpdate)
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package gunp
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/config"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+func TestPushRejectsNonFastForward(t *testing.T) {
+	remoteDir := filepath.Join(t.TempDir(), "remote.git")
+	if _, err := git.PlainInit(remoteDir, true); err != nil {
+		t.Fatal(err)
+	}
+	local := newTestRepo(t)
+	local.commit("base-1")
+	if _, err := local.repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remoteDir}}); err != nil {
+		t.Fatal(err)
+	}
+
+	local.commit("local-1")
+	plan, err := PlanPush(local.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := Push(plan); result.Status != PushOK {
+		t.Fatalf("got %s pushing to an empty remote: %v", result.Status, result.Err)
+	}
+	if result := Push(plan); result.Status != PushUpToDate {
+		t.Fatalf("got %s pushing again: %v", result.Status, result.Err)
+	}
+
+	// a second clone pushes second-1, the local branch diverges with local-2
+	secondDir := filepath.Join(t.TempDir(), "second")
+	second, err := git.PlainClone(secondDir, &git.CloneOptions{URL: remoteDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := second.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Commit("second-1", &git.CommitOptions{AllowEmptyCommits: true, Author: &object.Signature{Name: "Second", Email: "second@example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Push(&git.PushOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	local.commit("local-2")
+
+	result := Push(plan)
+	if result.Status != PushRejected || !errors.Is(result.Err, git.ErrNonFastForwardUpdate) {
+		t.Errorf("got %s (%v), want rejected as non-fast-forward", result.Status, result.Err)
+	}
+}
@@ -0,0 +1,137 @@
+package gunp
+
+import (
+	logger "gunp/internal/log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/config"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+func TestMain(m *testing.M) {
+	logger.Initialize()
+	os.Exit(m.Run())
+}
+
+// testRepo is a repo in a temp folder to build histories with
+type testRepo struct {
+	t    *testing.T
+	dir  string
+	repo *git.Repository
+	tree *git.Worktree
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	dir := t.TempDir()
+	r, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testRepo{t: t, dir: dir, repo: r, tree: tree}
+}
+
+// commit writes a file named after the message and commits it on HEAD,
+// with extra parents to make a merge commit
+func (tr *testRepo) commit(message string, parents ...plumbing.Hash) plumbing.Hash {
+	tr.t.Helper()
+	return tr.commitAt(message, time.Now(), parents...)
+}
+
+// commitAt is commit authored and committed at when
+func (tr *testRepo) commitAt(message string, when time.Time, parents ...plumbing.Hash) plumbing.Hash {
+	tr.t.Helper()
+	if err := os.WriteFile(filepath.Join(tr.dir, message+".txt"), []byte(message+"\n"), 0o644); err != nil {
+		tr.t.Fatal(err)
+	}
+	if _, err := tr.tree.Add(message + ".txt"); err != nil {
+		tr.t.Fatal(err)
+	}
+	opts := &git.CommitOptions{Author: &object.Signature{Name: "Test", Email: "test@example.com", When: when}}
+	if len(parents) > 0 {
+		opts.Parents = append([]plumbing.Hash{tr.head()}, parents...)
+	}
+	hash, err := tr.tree.Commit(message, opts)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	return hash
+}
+
+func (tr *testRepo) head() plumbing.Hash {
+	tr.t.Helper()
+	head, err := tr.repo.Head()
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	return head.Hash()
+}
+
+// branch is the short name of the branch checked out
+func (tr *testRepo) branch() string {
+	tr.t.Helper()
+	head, err := tr.repo.Head()
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	return head.Name().Short()
+}
+
+// checkout moves HEAD to the branch, created at from when it is not zero
+func (tr *testRepo) checkout(branch string, from plumbing.Hash) {
+	tr.t.Helper()
+	opts := &git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(branch), Force: true}
+	if !from.IsZero() {
+		opts.Hash, opts.Create = from, true
+	}
+	if err := tr.tree.Checkout(opts); err != nil {
+		tr.t.Fatal(err)
+	}
+}
+
+func (tr *testRepo) setRef(name string, hash plumbing.Hash) {
+	tr.t.Helper()
+	if err := tr.repo.Storer.SetReference(plumbing.NewHashReference(plumbing.ReferenceName(name), hash)); err != nil {
+		tr.t.Fatal(err)
+	}
+}
+
+// mergedUpstreamRepo builds a feature branch tracking origin/main that merged it:
+// base-1..base-5 are pushed, up-1 is on origin/main only, feature-1, the merge and feature-2 are unpushed
+func mergedUpstreamRepo(t *testing.T) *testRepo {
+	t.Helper()
+	tr := newTestRepo(t)
+	for _, message := range []string{"base-1", "base-2", "base-3", "base-4", "base-5"} {
+		tr.commit(message)
+	}
+	base := tr.head()
+	tr.checkout("up", base)
+	up := tr.commit("up-1")
+	tr.setRef("refs/remotes/origin/main", up)
+	if err := tr.repo.Storer.RemoveReference(plumbing.NewBranchReferenceName("up")); err != nil {
+		t.Fatal(err)
+	}
+
+	tr.checkout("feature", base)
+	cfg, err := tr.repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Branches["feature"] = &config.Branch{Name: "feature", Remote: "origin", Merge: plumbing.NewBranchReferenceName("main")}
+	if err := tr.repo.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	tr.commit("feature-1")
+	tr.commit("merge", up)
+	tr.commit("feature-2")
+	return tr
+}
@@ -0,0 +1,112 @@
+package gunp
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Report is the machine-readable output of a scan.
+type Report struct {
+	RootDir       string       `json:"rootDir"`
+	Repositories  int          `json:"repositories"`
+	UnpushedCount int          `json:"unpushedCount"`
+	StaleAfter    string       `json:"staleAfter,omitempty"`
+	StaleRepos    int          `json:"staleRepos"`
+	Author        string       `json:"author,omitempty"`
+	Mine          bool         `json:"mine,omitempty"`
+	Since         *time.Time   `json:"since,omitempty"`
+	Until         *time.Time   `json:"until,omitempty"`
+	Repos         []RepoReport `json:"repos"`
+}
+
+type RepoReport struct {
+	Path            string         `json:"path"`
+	UnpushedCount   int            `json:"unpushedCount"`
+	UnpushedCommits []CommitReport `json:"unpushedCommits"`
+	UnpushedTags    []string       `json:"unpushedTags,omitempty"`
+	LastFetch       *time.Time     `json:"lastFetch"`
+	OldestUnpushed  *time.Time     `json:"oldestUnpushed,omitempty"`
+	NewestUnpushed  *time.Time     `json:"newestUnpushed,omitempty"`
+	// Stale means the unpushed counts are unreliable since the remotes were not fetched recently
+	Stale      bool   `json:"stale"`
+	NoRemote   bool   `json:"noRemote,omitempty"`
+	FetchError string `json:"fetchError,omitempty"`
+	// AuthorError is why the commits of the user can't be counted with --mine, e.g. no user.email
+	AuthorError string `json:"authorError,omitempty"`
+}
+
+type CommitReport struct {
+	Hash               string    `json:"hash"`
+	Author             string    `json:"author"`
+	Email              string    `json:"email"`
+	Date               time.Time `json:"date"`
+	Message            string    `json:"message"`
+	EquivalentUpstream bool      `json:"equivalentUpstream,omitempty"`
+}
+
+// NewReport builds the report of the scanned repos, sorted by path.
+// Repos with nothing unpushed are left out unless they are stale, failed to fetch or to tell the commits of the user.
+func NewReport(rootDir string, gunpRepos []*GunpRepo, opts Options) Report {
+	report := Report{
+		RootDir:      rootDir,
+		Repositories: len(gunpRepos),
+		Repos:        []RepoReport{},
+	}
+	if opts.StaleAfter > 0 {
+		report.StaleAfter = opts.StaleAfter.String()
+	}
+	if opts.Author != nil {
+		report.Author = opts.Author.String()
+	}
+	report.Mine = opts.Author == nil && opts.Mine
+	report.Since, report.Until = opts.Since, opts.Until
+
+	for _, repo := range gunpRepos {
+		if repo.Stale {
+			report.StaleRepos++
+		}
+		if len(repo.UnpushedCommits) == 0 && len(repo.UnpushedTags) == 0 && !repo.Stale && repo.FetchErr == nil && repo.AuthorErr == nil {
+			continue
+		}
+		repoReport := RepoReport{
+			Path:            repo.Path,
+			UnpushedCount:   len(repo.UnpushedCommits),
+			UnpushedCommits: []CommitReport{},
+			UnpushedTags:    repo.UnpushedTags,
+			Stale:           repo.Stale,
+			NoRemote:        repo.NoRemote,
+		}
+		if repo.FetchErr != nil {
+			repoReport.FetchError = repo.FetchErr.Error()
+		}
+		if repo.AuthorErr != nil {
+			repoReport.AuthorError = repo.AuthorErr.Error()
+		}
+		if !repo.LastFetch.IsZero() {
+			lastFetch := repo.LastFetch
+			repoReport.LastFetch = &lastFetch
+		}
+		if !repo.OldestUnpushed.IsZero() {
+			oldest, newest := repo.OldestUnpushed, repo.NewestUnpushed
+			repoReport.OldestUnpushed, repoReport.NewestUnpushed = &oldest, &newest
+		}
+		for _, c := range repo.UnpushedCommits {
+			repoReport.UnpushedCommits = append(repoReport.UnpushedCommits, CommitReport{
+				Hash:               c.Hash.String(),
+				Author:             c.Author.Name,
+				Email:              c.Author.Email,
+				Date:               c.Author.When,
+				Message:            strings.TrimSpace(c.Message),
+				EquivalentUpstream: repo.IsEquivalent(c.Hash),
+			})
+		}
+		report.UnpushedCount += repoReport.UnpushedCount
+		report.Repos = append(report.Repos, repoReport)
+	}
+
+	sort.Slice(report.Repos, func(i, j int) bool {
+		return report.Repos[i].Path < report.Repos[j].Path
+	})
+	return report
+}
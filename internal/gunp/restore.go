@@ -0,0 +1,294 @@
+package gunp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	logger "gunp/internal/log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/config"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/format/packfile"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/go-git/go-git/v6/plumbing/transport"
+)
+
+type RestoreStatus int
+
+const (
+	RestoreCreated RestoreStatus = iota
+	RestoreFastForward
+	RestoreUpToDate
+	// RestoreAhead is a local branch that already has the saved commits and more, left as is
+	RestoreAhead
+	RestoreDiverged
+	RestoreForced
+	RestoreFailed
+)
+
+func (s RestoreStatus) String() string {
+	switch s {
+	case RestoreCreated:
+		return "created"
+	case RestoreFastForward:
+		return "fast-forwarded"
+	case RestoreUpToDate:
+		return "up-to-date"
+	case RestoreAhead:
+		return "ahead, kept"
+	case RestoreDiverged:
+		return "diverged, skipped (--force to overwrite)"
+	case RestoreForced:
+		return "diverged, overwritten"
+	}
+	return "failed"
+}
+
+// RestoreResult is the outcome of the restore of a branch of a bundle.
+type RestoreResult struct {
+	Entry  BundleEntry
+	Branch BundleBranch
+	// Recreated is true when the repo was not there anymore and was cloned again, or created empty without remotes
+	Recreated bool
+	Status    RestoreStatus
+	Err       error
+}
+
+// ReadArchive reads the manifest of a backup written by Bundle, archive is its folder or the manifest itself.
+func ReadArchive(archive string) (string, BundleManifest, error) {
+	dir := archive
+	if info, err := os.Stat(archive); err == nil && !info.IsDir() {
+		dir = filepath.Dir(archive)
+	}
+	manifest, err := ReadManifest(dir)
+	return dir, manifest, err
+}
+
+// Restore brings back the branches of the bundles of the archive into the repos they were made from.
+// A repo that is not at its path anymore is cloned again from its remote (origin first), or created empty without remotes.
+// A branch is created or fast-forwarded, a diverged branch is overwritten only with force.
+func Restore(archive string, force bool) ([]RestoreResult, error) {
+	dir, manifest, err := ReadArchive(archive)
+	if err != nil {
+		return nil, err
+	}
+	var results []RestoreResult
+	for _, entry := range manifest.Bundles {
+		results = append(results, restoreBundle(filepath.Join(dir, filepath.FromSlash(entry.File)), entry, force)...)
+	}
+	return results, nil
+}
+
+// restoreBundle restores the branches of a bundle, with a failed result for every branch when the bundle can't be unbundled
+func restoreBundle(bundlePath string, entry BundleEntry, force bool) []RestoreResult {
+	failed := func(recreated bool, err error) []RestoreResult {
+		logger.Get().Error("restore", "path", entry.Path, "bundle", bundlePath, "err", err)
+		var results []RestoreResult
+		for _, branch := range entry.Branches {
+			results = append(results, RestoreResult{Entry: entry, Branch: branch, Recreated: recreated, Status: RestoreFailed, Err: err})
+		}
+		return results
+	}
+
+	r, recreated, err := openOrClone(entry)
+	if err != nil {
+		return failed(recreated, err)
+	}
+	if err := unbundle(r, bundlePath); err != nil {
+		return failed(recreated, err)
+	}
+
+	var results []RestoreResult
+	for _, branch := range entry.Branches {
+		status, err := restoreBranch(r, branch, force)
+		if err != nil {
+			logger.Get().Error("restore", "path", entry.Path, "branch", branch.Name, "err", err)
+		}
+		results = append(results, RestoreResult{Entry: entry, Branch: branch, Recreated: recreated, Status: status, Err: err})
+	}
+	return results
+}
+
+// openOrClone opens the repo at the path of the entry, or clones it there again
+func openOrClone(entry BundleEntry) (*git.Repository, bool, error) {
+	r, err := git.PlainOpen(entry.Path)
+	if err == nil {
+		return r, false, nil
+	}
+	if !errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, false, err
+	}
+
+	names := make([]string, 0, len(entry.Remotes))
+	for name := range entry.Remotes {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		switch {
+		case a == "origin":
+			return -1
+		case b == "origin":
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+
+	_, statErr := os.Stat(entry.Path)
+	existed := statErr == nil
+	r, err = cloneAgain(entry, names)
+	if err != nil {
+		// a half-made repo would be opened as is by the next restore
+		// only the .git is ours in a folder that was already there
+		var removeErr error
+		if existed {
+			removeErr = os.RemoveAll(filepath.Join(entry.Path, git.GitDirName))
+		} else {
+			removeErr = os.RemoveAll(entry.Path)
+		}
+		return nil, false, errors.Join(err, removeErr)
+	}
+	return r, true, nil
+}
+
+// cloneAgain creates the repo at the path of the entry with its remotes, fetching the first one.
+// It is an init and a fetch rather than a clone: the remote HEAD isn't resolved nor checked out,
+// the first branch of the bundle is checked out once restored.
+func cloneAgain(entry BundleEntry, names []string) (*git.Repository, error) {
+	var options []git.InitOption
+	if len(entry.Branches) > 0 {
+		options = append(options, git.WithDefaultBranch(plumbing.ReferenceName(entry.Branches[0].Name)))
+	}
+	r, err := git.PlainInit(entry.Path, false, options...)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if _, err := r.CreateRemote(&config.RemoteConfig{Name: name, URLs: []string{entry.Remotes[name]}}); err != nil {
+			return nil, err
+		}
+	}
+	if len(names) == 0 {
+		return r, nil
+	}
+	err = r.Fetch(&git.FetchOptions{RemoteName: names[0]})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) && !errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return nil, fmt.Errorf("clone %s: %w", entry.Remotes[names[0]], err)
+	}
+	return r, nil
+}
+
+// unbundle adds the objects of a bundle to the repo, which must have its prerequisites
+func unbundle(r *git.Repository, bundlePath string) error {
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	header, err := readBundleHeader(reader)
+	if err != nil {
+		return err
+	}
+	for _, prerequisite := range header.Prerequisites {
+		if _, err := r.Storer.EncodedObject(plumbing.CommitObject, prerequisite); err != nil {
+			return fmt.Errorf("the repo lacks the prerequisite %s of the bundle: %w", prerequisite, err)
+		}
+	}
+	return packfile.UpdateObjectStorage(r.Storer, reader)
+}
+
+// restoreBranch points the branch to its saved commit when it is missing or behind, or diverged with force.
+// The branch checked out is updated with its worktree, only when the worktree is clean.
+func restoreBranch(r *git.Repository, branch BundleBranch, force bool) (RestoreStatus, error) {
+	name := plumbing.ReferenceName(branch.Name)
+	saved, err := r.CommitObject(plumbing.NewHash(branch.Hash))
+	if err != nil {
+		return RestoreFailed, err
+	}
+
+	status := RestoreCreated
+	if ref, err := r.Reference(name, true); err == nil {
+		local, err := r.CommitObject(ref.Hash())
+		if err != nil {
+			return RestoreFailed, err
+		}
+		switch {
+		case local.Hash == saved.Hash:
+			return RestoreUpToDate, nil
+		case isAncestor(local, saved):
+			status = RestoreFastForward
+		case isAncestor(saved, local):
+			return RestoreAhead, nil
+		case !force:
+			return RestoreDiverged, nil
+		default:
+			status = RestoreForced
+		}
+	} else if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return RestoreFailed, err
+	}
+
+	// the worktree of the branch checked out follows it, HEAD may be unborn in a repo created empty
+	var w *git.Worktree
+	if head, err := r.Storer.Reference(plumbing.HEAD); err == nil && head.Target() == name {
+		if w, err = r.Worktree(); err != nil && !errors.Is(err, git.ErrIsBareRepository) {
+			return RestoreFailed, err
+		}
+	}
+	if w != nil {
+		worktreeStatus, err := w.Status()
+		if err != nil {
+			return RestoreFailed, err
+		}
+		if !worktreeStatus.IsClean() {
+			return RestoreFailed, fmt.Errorf("%s is checked out with local changes, commit or stash them first", name.Short())
+		}
+	}
+	if err := r.Storer.SetReference(plumbing.NewHashReference(name, saved.Hash)); err != nil {
+		return RestoreFailed, err
+	}
+	if w != nil {
+		if err := w.Reset(&git.ResetOptions{Commit: saved.Hash, Mode: git.HardReset}); err != nil {
+			return RestoreFailed, err
+		}
+	}
+
+	if err := trackUpstream(r, branch); err != nil {
+		return status, err
+	}
+	return status, nil
+}
+
+// trackUpstream sets the upstream of a restored branch as it was, unless it already has one
+func trackUpstream(r *git.Repository, branch BundleBranch) error {
+	if branch.Upstream == "" {
+		return nil
+	}
+	cfg, err := r.Config()
+	if err != nil {
+		return err
+	}
+	short := plumbing.ReferenceName(branch.Name).Short()
+	if b := cfg.Branches[short]; b != nil && b.Remote != "" {
+		return nil
+	}
+	for name := range cfg.Remotes {
+		if merge, ok := strings.CutPrefix(branch.Upstream, "refs/remotes/"+name+"/"); ok {
+			cfg.Branches[short] = &config.Branch{Name: short, Remote: name, Merge: plumbing.NewBranchReferenceName(merge)}
+			return r.SetConfig(cfg)
+		}
+	}
+	return nil
+}
+
+// isAncestor reports whether a is an ancestor of b, false on error
+func isAncestor(a, b *object.Commit) bool {
+	ok, err := a.IsAncestor(b)
+	return err == nil && ok
+}
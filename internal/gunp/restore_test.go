@@ -0,0 +1,106 @@
+package gunp
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/config"
+	"github.com/go-git/go-git/v6/plumbing"
+)
+
+// danglingHeadRemote is a bare remote with main, whose HEAD points to a branch that doesn't exist
+func danglingHeadRemote(t *testing.T) string {
+	t.Helper()
+	remoteDir := filepath.Join(t.TempDir(), "remote.git")
+	remote, err := git.PlainInit(remoteDir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := newTestRepo(t)
+	tr.commit("base-1")
+	head, err := tr.repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remoteDir}}); err != nil {
+		t.Fatal(err)
+	}
+	err = tr.repo.Push(&git.PushOptions{RemoteName: "origin", RefSpecs: []config.RefSpec{config.RefSpec(head.Name() + ":refs/heads/main")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/missing")); err != nil {
+		t.Fatal(err)
+	}
+	return remoteDir
+}
+
+func TestOpenOrCloneDanglingRemoteHead(t *testing.T) {
+	entry := BundleEntry{
+		Path:     filepath.Join(t.TempDir(), "repo"),
+		Remotes:  map[string]string{"origin": danglingHeadRemote(t)},
+		Branches: []BundleBranch{{Name: "refs/heads/feature"}},
+	}
+	r, recreated, err := openOrClone(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recreated {
+		t.Error("the repo was not reported as recreated")
+	}
+	if _, err := r.Reference("refs/remotes/origin/main", true); err != nil {
+		t.Errorf("origin/main was not fetched: %v", err)
+	}
+	head, err := r.Storer.Reference(plumbing.HEAD)
+	if err != nil || head.Target() != "refs/heads/feature" {
+		t.Errorf("HEAD is %v, want the first branch of the bundle", head)
+	}
+}
+
+func TestOpenOrCloneRemovesFailedClone(t *testing.T) {
+	entry := BundleEntry{
+		Path:     filepath.Join(t.TempDir(), "repo"),
+		Remotes:  map[string]string{"origin": filepath.Join(t.TempDir(), "missing.git")},
+		Branches: []BundleBranch{{Name: "refs/heads/main"}},
+	}
+	for range 2 {
+		if _, _, err := openOrClone(entry); err == nil {
+			t.Fatal("the clone of a missing remote succeeded")
+		}
+		if _, err := os.Stat(entry.Path); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("the failed clone was left at %s: %v", entry.Path, err)
+		}
+	}
+}
+
+func TestOpenOrCloneKeepsExistingFolder(t *testing.T) {
+	entry := BundleEntry{
+		Path:     filepath.Join(t.TempDir(), "repo"),
+		Remotes:  map[string]string{"origin": filepath.Join(t.TempDir(), "missing.git")},
+		Branches: []BundleBranch{{Name: "refs/heads/main"}},
+	}
+	userFile := filepath.Join(entry.Path, "notes.txt")
+	if err := os.MkdirAll(entry.Path, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(userFile, []byte("keep me\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, recreated, err := openOrClone(entry)
+	if err == nil {
+		t.Fatal("the clone of a missing remote succeeded")
+	}
+	if recreated {
+		t.Error("a failed clone was reported as recreated")
+	}
+	if _, err := os.Stat(userFile); err != nil {
+		t.Fatalf("the file of the folder was removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(entry.Path, git.GitDirName)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the .git of the failed clone was left: %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package gunp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	logger "gunp/internal/log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/go-git/go-git/v6/plumbing/transport"
+)
+
+// remoteTagsRecord is where the last-known remote tags are stored, relative to the .git directory
+const remoteTagsRecord = "gunp/remote-tags"
+
+// listTimeout is the timeout in seconds when listing the refs of a remote
+const listTimeout = 30
+
+// GetUnpushedTags returns the names of the local tags that the remotes are not known to have.
+//
+// When a record of the remote tags exists (see RefreshRemoteTags) a tag is unpushed
+// if the record doesn't have it with the same hash. Without a record the remote-tracking
+// refs are used instead: a tag is unpushed if its commit is not reachable from any of them.
+func GetUnpushedTags(repo *git.Repository, gitDir string) []string {
+	var tags []string
+
+	tagRefs, err := repo.Tags()
+	if err != nil {
+		logger.Get().Error("get TAGS", "gitDir", gitDir, "err", err)
+		return tags
+	}
+	defer tagRefs.Close()
+
+	record, hasRecord := readRemoteTags(gitDir)
+	var reachable map[plumbing.Hash]bool
+
+	_ = tagRefs.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().Short()
+		if hasRecord {
+			if record[name] != ref.Hash() {
+				tags = append(tags, name)
+			}
+			return nil
+		}
+
+		commit, err := tagCommit(repo, ref)
+		if err != nil {
+			logger.Get().Debug("tag without commit", "tag", name, "err", err)
+			tags = append(tags, name)
+			return nil
+		}
+		if reachable == nil {
+			reachable = remoteReachable(repo)
+		}
+		if !reachable[commit.Hash] {
+			tags = append(tags, name)
+		}
+		return nil
+	})
+
+	sort.Strings(tags)
+	return tags
+}
+
+// RefreshRemoteTags lists the tags of every remote (like `git ls-remote --tags`)
+// and saves them as the record used by GetUnpushedTags.
+// The tags of the remotes that could be listed are saved even when others fail, their errors are returned.
+func RefreshRemoteTags(repo *git.Repository, gitDir string) error {
+	remotes, err := repo.Remotes()
+	if err != nil {
+		return err
+	}
+
+	record := map[string]plumbing.Hash{}
+	listed := 0
+	var errs []error
+	for _, remote := range remotes {
+		refs, err := remote.List(&git.ListOptions{Timeout: listTimeout})
+		if err != nil && !errors.Is(err, transport.ErrEmptyRemoteRepository) {
+			errs = append(errs, fmt.Errorf("list remote %s: %w", remote.Config().Name, err))
+			continue
+		}
+		listed++
+		for _, ref := range refs {
+			if ref.Name().IsTag() {
+				record[ref.Name().Short()] = ref.Hash()
+			}
+		}
+	}
+
+	if listed > 0 {
+		errs = append(errs, writeRemoteTags(gitDir, record))
+	}
+	return errors.Join(errs...)
+}
+
+func tagCommit(repo *git.Repository, ref *plumbing.Reference) (*object.Commit, error) {
+	tag, err := repo.TagObject(ref.Hash())
+	switch err {
+	case nil:
+		// annotated tag
+		return tag.Commit()
+	case plumbing.ErrObjectNotFound:
+		// lightweight tag
+		return repo.CommitObject(ref.Hash())
+	default:
+		return nil, err
+	}
+}
+
+// remoteReachable collects every commit reachable from the remote-tracking refs.
+func remoteReachable(repo *git.Repository) map[plumbing.Hash]bool {
+	reachable := map[plumbing.Hash]bool{}
+
+	refs, err := repo.References()
+	if err != nil {
+		logger.Get().Error("get REFERENCES", "err", err)
+		return reachable
+	}
+	defer refs.Close()
+
+	_ = refs.ForEach(func(ref *plumbing.Reference) error {
+		if !ref.Name().IsRemote() || ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		commit, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			return nil
+		}
+		iter := object.NewCommitPreorderIter(commit, reachable, nil)
+		defer iter.Close()
+		return iter.ForEach(func(c *object.Commit) error {
+			reachable[c.Hash] = true
+			return nil
+		})
+	})
+
+	return reachable
+}
+
+func readRemoteTags(gitDir string) (map[string]plumbing.Hash, bool) {
+	f, err := os.Open(filepath.Join(gitDir, ".git", remoteTagsRecord))
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	record := map[string]plumbing.Hash{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		hash, name, ok := strings.Cut(scanner.Text(), " ")
+		if ok {
+			record[name] = plumbing.NewHash(hash)
+		}
+	}
+	return record, true
+}
+
+func writeRemoteTags(gitDir string, record map[string]plumbing.Hash) error {
+	path := filepath.Join(gitDir, ".git", remoteTagsRecord)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(record))
+	for name := range record {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s %s\n", record[name], name)
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
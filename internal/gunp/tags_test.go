@@ -0,0 +1,77 @@
+package gunp
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/config"
+	"github.com/go-git/go-git/v6/plumbing"
+)
+
+func (tr *testRepo) tag(name string, hash plumbing.Hash) {
+	tr.t.Helper()
+	if _, err := tr.repo.CreateTag(name, hash, nil); err != nil {
+		tr.t.Fatal(err)
+	}
+}
+
+func TestGetUnpushedTagsWithoutRecord(t *testing.T) {
+	tr := newTestRepo(t)
+	pushed := tr.commit("pushed")
+	tr.setRef("refs/remotes/origin/main", pushed)
+	local := tr.commit("local")
+	tr.tag("v1", pushed)
+	tr.tag("v2", local)
+
+	got := GetUnpushedTags(tr.repo, filepath.Join(tr.dir, git.GitDirName))
+	if !slices.Equal(got, []string{"v2"}) {
+		t.Errorf("got %v, want [v2]", got)
+	}
+}
+
+func TestGetUnpushedTagsWithRecord(t *testing.T) {
+	tr := newTestRepo(t)
+	first := tr.commit("first")
+	second := tr.commit("second")
+	tr.setRef("refs/remotes/origin/main", second)
+	tr.tag("v1", first)
+	tr.tag("v2", second)
+	tr.tag("v3", second)
+
+	// v2 was moved on the remote and v3 was never pushed, even though its commit was
+	gitDir := filepath.Join(tr.dir, git.GitDirName)
+	if err := writeRemoteTags(gitDir, map[string]plumbing.Hash{"v1": first, "v2": first}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := GetUnpushedTags(tr.repo, gitDir)
+	if !slices.Equal(got, []string{"v2", "v3"}) {
+		t.Errorf("got %v, want [v2 v3]", got)
+	}
+}
+
+func TestRefreshRemoteTagsKeepsListedRemotes(t *testing.T) {
+	remote := newTestRepo(t)
+	remote.tag("v1", remote.commit("first"))
+
+	tr := newTestRepo(t)
+	for name, url := range map[string]string{"good": remote.dir, "bad": filepath.Join(t.TempDir(), "missing")} {
+		if _, err := tr.repo.CreateRemote(&config.RemoteConfig{Name: name, URLs: []string{url}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gitDir := filepath.Join(tr.dir, git.GitDirName)
+	if err := RefreshRemoteTags(tr.repo, gitDir); err == nil {
+		t.Error("want the error of the bad remote")
+	}
+	record, ok := readRemoteTags(gitDir)
+	if !ok {
+		t.Fatal("no record written")
+	}
+	if record["v1"] != remote.head() {
+		t.Errorf("got record %v, want v1 of the good remote", record)
+	}
+}
@@ -2,8 +2,10 @@ package logger
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/lmittmann/tint" // Nice colored output
@@ -39,26 +41,129 @@ func (l *Logger) Print(msg string, args ...any) {
 
 var instance *Logger
 
+// logFile is the file of the logs, closed when Configure replaces it
+var logFile *os.File
+
+// ringHandler keeps the records for the log panel of the TUI, across Configure
+var ringHandler = NewRingHandler(ringSize, slog.LevelDebug)
+
+// Options sets where the logs go, see Configure
+type Options struct {
+	// Level is the minimum level logged, LevelSilent logs nothing
+	Level slog.Level
+	// File receives the logs instead of stdout, appending
+	File string
+	// Format is "text" or "json"
+	Format string
+	// TUI keeps stdout to the Terminal UI: without File, the logs only go to its log panel
+	TUI bool
+}
+
+// ParseLevel parses a level by name, the custom levels included: trace, dev, debug, info, warn, error, silent
+func ParseLevel(name string) (slog.Level, error) {
+	for leveler, levelName := range LevelNames {
+		if levelName != "" && strings.EqualFold(levelName, name) {
+			return leveler.Level(), nil
+		}
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return level, fmt.Errorf("unknown log level %q (trace, dev, debug, info, warn, error, silent)", name)
+	}
+	return level, nil
+}
+
 func Initialize() {
-	opts := &tint.Options{
-		// Level:      LevelTrace,
-		Level:      LevelSilent,
-		TimeFormat: time.Kitchen,
-		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
-			if a.Key == slog.LevelKey {
-				level := a.Value.Any().(slog.Level)
-				if name, ok := LevelNames[level]; ok {
-					a.Value = slog.StringValue(name)
-				}
-			}
-			return a
-		},
+	// the default options can't fail
+	_ = Configure(Options{Level: LevelSilent, Format: "text"})
+}
+
+// Configure replaces the handlers of the logger. The messages printed for the user always go to stdout,
+// the logs go to stdout too, or to the file. With TUI, nothing goes to stdout.
+func Configure(opts Options) error {
+	out := os.Stdout
+	if opts.File != "" {
+		file, err := os.OpenFile(opts.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		out = file
+	}
+
+	var handler slog.Handler
+	switch opts.Format {
+	case "", "text":
+		handler = tint.NewHandler(out, &tint.Options{
+			Level:       opts.Level,
+			TimeFormat:  time.Kitchen,
+			ReplaceAttr: replaceLevelName,
+			NoColor:     opts.File != "",
+		})
+	case "json":
+		handler = slog.NewJSONHandler(out, &slog.HandlerOptions{
+			Level:       opts.Level,
+			ReplaceAttr: replaceLevelName,
+		})
+	default:
+		if opts.File != "" {
+			out.Close()
+		}
+		return fmt.Errorf("unknown log format %q (text, json)", opts.Format)
+	}
+
+	handlers := multiHandler{ringHandler}
+	switch {
+	case opts.File != "" && opts.TUI:
+		handlers = append(handlers, logsOnly{handler})
+	case opts.File != "":
+		userHandler := tint.NewHandler(os.Stdout, &tint.Options{
+			Level:       LevelUser,
+			TimeFormat:  time.Kitchen,
+			ReplaceAttr: replaceLevelName,
+		})
+		handlers = append(handlers, logsOnly{handler}, userHandler)
+	case !opts.TUI:
+		handlers = append(handlers, handler)
 	}
 
 	instance = &Logger{
-		Logger: slog.New(tint.NewHandler(os.Stdout, opts)),
+		Logger: slog.New(handlers),
 	}
 	slog.SetDefault(instance.Logger)
+	if logFile != nil {
+		logFile.Close()
+	}
+	logFile = nil
+	if opts.File != "" {
+		logFile = out
+	}
+	return nil
+}
+
+func replaceLevelName(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey {
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			a.Value = slog.StringValue(LevelName(level))
+		}
+	}
+	return a
+}
+
+// logsOnly leaves out the messages printed for the user
+type logsOnly struct {
+	slog.Handler
+}
+
+func (h logsOnly) Enabled(ctx context.Context, level slog.Level) bool {
+	return level < LevelSilent && h.Handler.Enabled(ctx, level)
+}
+
+func (h logsOnly) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return logsOnly{h.Handler.WithAttrs(attrs)}
+}
+
+func (h logsOnly) WithGroup(name string) slog.Handler {
+	return logsOnly{h.Handler.WithGroup(name)}
 }
 
 func Get() *Logger {
@@ -67,3 +172,8 @@ func Get() *Logger {
 	}
 	return instance
 }
+
+// Records returns the last log records, the oldest first
+func Records() []Record {
+	return ringHandler.Records()
+}
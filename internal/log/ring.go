@@ -0,0 +1,184 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ringSize is the number of records kept for the log panel of the TUI,
+// as many warnings and errors are kept apart from the other records
+const ringSize = 1000
+
+// Record is a log record kept in memory, with its attributes already formatted
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   string
+}
+
+func (r Record) String() string {
+	line := fmt.Sprintf("%s %-5s %s", r.Time.Format(time.TimeOnly), LevelName(r.Level), r.Message)
+	if r.Attrs != "" {
+		line += " " + r.Attrs
+	}
+	return line
+}
+
+// LevelName is the name of the level, custom levels included
+func LevelName(level slog.Level) string {
+	if name, ok := LevelNames[level]; ok {
+		return name
+	}
+	return level.String()
+}
+
+type ring struct {
+	mu      sync.Mutex
+	records []Record
+	next    int
+	full    bool
+}
+
+func newRing(size int) *ring {
+	return &ring{records: make([]Record, size)}
+}
+
+func (r *ring) add(record Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[r.next] = record
+	r.next = (r.next + 1) % len(r.records)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// all returns the records kept, the oldest first
+func (r *ring) all() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]Record{}, r.records[:r.next]...)
+	}
+	return append(append([]Record{}, r.records[r.next:]...), r.records[:r.next]...)
+}
+
+// RingHandler keeps the last records in memory instead of writing them, to show them inside the TUI
+// without corrupting the alt-screen. The warnings and errors have their own ring,
+// the debug records of a big scan can't push them out.
+type RingHandler struct {
+	ring     *ring
+	warnings *ring
+	level    slog.Leveler
+	attrs    string
+	group    string
+}
+
+func NewRingHandler(size int, level slog.Leveler) *RingHandler {
+	return &RingHandler{ring: newRing(size), warnings: newRing(size), level: level}
+}
+
+// Enabled skips the messages printed for the user, they are not logs
+func (h *RingHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && level < LevelSilent
+}
+
+func (h *RingHandler) Handle(_ context.Context, r slog.Record) error {
+	var attrs []string
+	if h.attrs != "" {
+		attrs = append(attrs, h.attrs)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, h.formatAttr(a))
+		return true
+	})
+
+	record := Record{Time: r.Time, Level: r.Level, Message: r.Message, Attrs: strings.Join(attrs, " ")}
+	if r.Level >= slog.LevelWarn {
+		h.warnings.add(record)
+	} else {
+		h.ring.add(record)
+	}
+	return nil
+}
+
+func (h *RingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	formatted := make([]string, 0, len(attrs)+1)
+	if h.attrs != "" {
+		formatted = append(formatted, h.attrs)
+	}
+	for _, a := range attrs {
+		formatted = append(formatted, h.formatAttr(a))
+	}
+	h2 := *h
+	h2.attrs = strings.Join(formatted, " ")
+	return &h2
+}
+
+func (h *RingHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.group = h.group + name + "."
+	return &h2
+}
+
+func (h *RingHandler) formatAttr(a slog.Attr) string {
+	return fmt.Sprintf("%s%s=%v", h.group, a.Key, a.Value.Resolve())
+}
+
+// Records returns the records kept, the oldest first
+func (h *RingHandler) Records() []Record {
+	records := append(h.ring.all(), h.warnings.all()...)
+	slices.SortStableFunc(records, func(a, b Record) int {
+		return a.Time.Compare(b.Time)
+	})
+	return records
+}
+
+// multiHandler sends the records to every handler enabled for their level
+type multiHandler []slog.Handler
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range m {
+		if h.Enabled(ctx, r.Level) {
+			if err := h.Handle(ctx, r.Clone()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, h := range m {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, h := range m {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
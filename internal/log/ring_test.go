@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestRingHandlerKeepsWarnings(t *testing.T) {
+	l := slog.New(NewRingHandler(10, slog.LevelDebug))
+	h := l.Handler().(*RingHandler)
+	l.Error("repo failed", "gitDir", "/work/a")
+	l.Warn("repo stale")
+	for range 50 {
+		l.Debug("commit")
+	}
+
+	records := h.Records()
+	if len(records) != 12 {
+		t.Fatalf("got %d records, want the 10 last debug ones and the 2 warnings", len(records))
+	}
+	if records[0].Message != "repo failed" || records[0].Attrs != "gitDir=/work/a" || records[1].Message != "repo stale" {
+		t.Errorf("the warnings were pushed out by the debug records: %v, %v", records[0], records[1])
+	}
+	for i := 1; i < len(records); i++ {
+		if records[i].Time.Before(records[i-1].Time) {
+			t.Fatalf("the records are not the oldest first: %v", records)
+		}
+	}
+}